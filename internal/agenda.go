@@ -0,0 +1,174 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateLayout is how due/scheduled dates are shown and accepted on the CLI.
+const dateLayout = "2006-01-02"
+
+// dayStart returns local midnight of t's calendar date (as written, so a
+// date saved in another zone keeps its day).
+func dayStart(t time.Time) time.Time {
+	y, mo, d := t.Date()
+	return time.Date(y, mo, d, 0, 0, 0, 0, time.Local)
+}
+
+// parseDate accepts YYYY-MM-DD, today/tomorrow/yesterday and relative
+// offsets like +3d or +2w.
+func parseDate(s string, now time.Time) (time.Time, error) {
+	today := dayStart(now)
+	switch strings.ToLower(s) {
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+	if len(s) > 2 && (s[0] == '+' || s[0] == '-') {
+		n, err := strconv.Atoi(s[1 : len(s)-1])
+		if err == nil {
+			if s[0] == '-' {
+				n = -n
+			}
+			switch s[len(s)-1] {
+			case 'd':
+				return today.AddDate(0, 0, n), nil
+			case 'w':
+				return today.AddDate(0, 0, 7*n), nil
+			}
+		}
+	}
+	t, err := time.ParseInLocation(dateLayout, s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("bad date %q (want YYYY-MM-DD, today, tomorrow or +Nd)", s)
+	}
+	return t, nil
+}
+
+// doSetDate sets (or clears with "none") the due or scheduled date of an item.
+func doSetDate(kind string, userIndex int, value string) int {
+	items, err := Load()
+	if err != nil {
+		fail("load: " + err.Error())
+		return 1
+	}
+	if userIndex < 1 || userIndex > len(items) {
+		fail(fmt.Sprintf("index out of range: have %d, got %d", len(items), userIndex))
+		fmt.Fprintln(os.Stderr, mutedStyle.Render("Hint: run `todo ls` to see valid indexes"))
+		return 2
+	}
+	var d *time.Time
+	if value != "none" {
+		t, err := parseDate(value, time.Now())
+		if err != nil {
+			fail(kind + ": " + err.Error())
+			return 2
+		}
+		d = &t
+	}
+	it := &items[userIndex-1]
+	if kind == "due" {
+		it.Due = d
+	} else {
+		it.Scheduled = d
+	}
+	if err := Save(items); err != nil {
+		fail("save: " + err.Error())
+		return 1
+	}
+	if d == nil {
+		ok(kind + " cleared")
+	} else {
+		ok(kind + " " + d.Format(dateLayout))
+	}
+	return 0
+}
+
+// agendaEntry is one line of the agenda: an item on a given day.
+type agendaEntry struct {
+	Index int // 1-based, as accepted by done/rm
+	Item  Item
+	Kind  string // "due" | "scheduled"
+	Day   time.Time
+}
+
+// agendaEntries lists pending items by their due and scheduled days.
+// An item scheduled on its due day is listed once, as due.
+func agendaEntries(items []Item) []agendaEntry {
+	var out []agendaEntry
+	for i, it := range items {
+		if it.Done {
+			continue
+		}
+		if it.Due != nil {
+			out = append(out, agendaEntry{Index: i + 1, Item: it, Kind: "due", Day: dayStart(*it.Due)})
+		}
+		if it.Scheduled != nil {
+			day := dayStart(*it.Scheduled)
+			if it.Due == nil || !day.Equal(dayStart(*it.Due)) {
+				out = append(out, agendaEntry{Index: i + 1, Item: it, Kind: "scheduled", Day: day})
+			}
+		}
+	}
+	sort.SliceStable(out, func(a, b int) bool { return out[a].Day.Before(out[b].Day) })
+	return out
+}
+
+func doAgenda(days int) int {
+	items, err := Load()
+	if err != nil {
+		fail("load: " + err.Error())
+		return 1
+	}
+	today := dayStart(time.Now())
+	end := today.AddDate(0, 0, days)
+
+	var overdue []agendaEntry
+	byDay := map[time.Time][]agendaEntry{}
+	for _, e := range agendaEntries(items) {
+		switch {
+		case e.Day.Before(today):
+			overdue = append(overdue, e)
+		case e.Day.Before(end):
+			byDay[e.Day] = append(byDay[e.Day], e)
+		}
+	}
+
+	if len(overdue) > 0 {
+		fmt.Println(errorStyle.Render("Overdue"))
+		for _, e := range overdue {
+			ago := int(today.Sub(e.Day).Round(24*time.Hour) / (24 * time.Hour))
+			printAgendaLine(e, fmt.Sprintf("%s %s (%dd ago)", e.Kind, e.Day.Format(dateLayout), ago))
+		}
+		fmt.Println()
+	}
+	for d := today; d.Before(end); d = d.AddDate(0, 0, 1) {
+		label := d.Format("Mon 02 Jan")
+		if d.Equal(today) {
+			label = "Today · " + label
+		}
+		fmt.Println(titleStyle.Render(label))
+		if len(byDay[d]) == 0 {
+			fmt.Println(mutedStyle.Render("       nothing planned"))
+		}
+		for _, e := range byDay[d] {
+			printAgendaLine(e, e.Kind)
+		}
+	}
+	return 0
+}
+
+func printAgendaLine(e agendaEntry, note string) {
+	box := mutedStyle.Render(boxUnchecked)
+	if e.Item.Status == StatusDoing {
+		box = pendingStyle.Render(boxDoing)
+	}
+	fmt.Printf("  %3d  %s %s  %s\n", e.Index, box, e.Item.Title, mutedStyle.Render(note))
+}
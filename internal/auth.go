@@ -1,13 +1,16 @@
 package internal
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/charmbracelet/x/term"
 )
 
 const credFileName = "credentials.json"
@@ -17,6 +20,13 @@ type TokenInfo struct {
 	Source    string     `json:"source"`     // "env" | "file"
 	CreatedAt time.Time  `json:"created_at"` // when we saved to file
 	ExpiresAt *time.Time `json:"expires_at"` // optional (JWT or server-provided)
+
+	// Claims read from a JWT token (see jwt.go); empty for opaque tokens
+	IssuedAt *time.Time `json:"issued_at,omitempty"`
+	Subject  string     `json:"subject,omitempty"`
+	Audience []string   `json:"audience,omitempty"`
+
+	Profile string `json:"-"` // profile the token belongs to
 }
 
 func credsDir() (string, error) {
@@ -35,76 +45,68 @@ func credFilePath() (string, error) {
 	return filepath.Join(dir, credFileName), nil
 }
 
+// GetToken returns the token to use: TADA_TOKEN, else the active profile's
+// (see profiles.go). It returns nil when not logged in.
 func GetToken() (*TokenInfo, error) {
+	name := profileName()
+
 	// 1) env override
 	env := strings.TrimSpace(os.Getenv("TADA_TOKEN"))
 	if env != "" {
-		return &TokenInfo{Token: stripBearer(env), Source: "env"}, nil
+		ti := &TokenInfo{Token: stripBearer(env), Source: "env", Profile: name}
+		ti.applyClaims()
+		return ti, nil
 	}
 
 	// 2) file
-	p, err := credFilePath()
+	creds, err := loadCredentials()
 	if err != nil {
 		return nil, err
 	}
-	b, err := os.ReadFile(p)
-	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return nil, nil // not logged in
-		}
-		return nil, fmt.Errorf("read credentials: %w", err)
-	}
-	var ti TokenInfo
-	if err := json.Unmarshal(b, &ti); err != nil {
-		return nil, fmt.Errorf("parse credentials: %w", err)
+	pr := creds.Profiles[name]
+	if pr == nil || pr.Token == "" {
+		return nil, nil // not logged in
 	}
+	ti := pr.TokenInfo
 	ti.Token = stripBearer(ti.Token)
+	ti.Profile = name
+	ti.applyClaims() // files saved before claims were recorded
 	return &ti, nil
 }
 
+// SetToken stores token in the active profile.
 func SetToken(token string, expires *time.Time) error {
+	return setProfileToken(profileName(), token, expires)
+}
+
+func setProfileToken(name, token string, expires *time.Time) error {
 	token = stripBearer(strings.TrimSpace(token))
 	if token == "" {
 		return fmt.Errorf("empty token")
 	}
-	dir, err := credsDir()
+	creds, err := loadCredentials()
 	if err != nil {
 		return err
 	}
-	// ensure ~/.tada exists with 0700
-	if err := os.MkdirAll(dir, 0o700); err != nil {
-		return fmt.Errorf("mkdir: %w", err)
+	pr := creds.Profiles[name]
+	if pr == nil {
+		pr = &Profile{}
+		creds.Profiles[name] = pr
 	}
-	ti := TokenInfo{
+	pr.TokenInfo = TokenInfo{
 		Token:     token,
 		Source:    "file",
 		CreatedAt: time.Now(),
 		ExpiresAt: expires,
 	}
-	b, err := json.MarshalIndent(ti, "", "  ")
-	if err != nil {
-		return fmt.Errorf("marshal: %w", err)
-	}
-	p, _ := credFilePath()
-	// write with 0600 (owner-only)
-	if err := os.WriteFile(p, b, 0o600); err != nil {
-		return fmt.Errorf("write: %w", err)
-	}
-	return nil
+	pr.OAuth = nil // a pasted token cannot be refreshed
+	pr.applyClaims()
+	return saveCredentials(creds)
 }
 
+// DeleteToken removes the active profile's token, keeping the profile.
 func DeleteToken() error {
-	p, err := credFilePath()
-	if err != nil {
-		return err
-	}
-	if err := os.Remove(p); err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return nil
-		}
-		return fmt.Errorf("remove: %w", err)
-	}
-	return nil
+	return clearProfileToken(profileName())
 }
 
 func stripBearer(s string) string {
@@ -113,3 +115,82 @@ func stripBearer(s string) string {
 	}
 	return s
 }
+
+// maxTokenBytes bounds what --with-token reads from stdin.
+const maxTokenBytes = 64 << 10
+
+// readToken reads a token for `todo auth login`. On a terminal it prompts
+// without echo; with --with-token (or when stdin is piped) it reads all of
+// stdin. Surrounding whitespace and a "Bearer " prefix are dropped, so
+// pasted header values work.
+func readToken(in *os.File, withToken bool) (string, error) {
+	var raw string
+	if !withToken && term.IsTerminal(in.Fd()) {
+		fmt.Fprint(os.Stderr, "Paste your token (input is hidden): ")
+		b, err := term.ReadPassword(in.Fd())
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		raw = string(b)
+	} else {
+		b, err := io.ReadAll(io.LimitReader(in, maxTokenBytes+1))
+		if err != nil {
+			return "", err
+		}
+		if len(b) > maxTokenBytes {
+			return "", errors.New("token too long")
+		}
+		raw = string(b)
+	}
+	token := stripBearer(strings.TrimSpace(raw))
+	switch {
+	case token == "":
+		return "", errors.New("empty token")
+	case strings.ContainsAny(token, " \t\r\n"):
+		return "", errors.New("the token contains whitespace (one token expected)")
+	}
+	return token, nil
+}
+
+// parseExpires reads --expires: a duration from now ("12h", "30d", "2w";
+// days and weeks count 24h and 7×24h from now), a date ("2026-12-31",
+// midnight local time) or an RFC 3339 time.
+func parseExpires(s string, now time.Time) (time.Time, error) {
+	var t time.Time
+	var err error
+	if d, derr := time.ParseDuration(s); derr == nil {
+		t = now.Add(d)
+	} else if d, isDays := parseDays(s); isDays {
+		t = now.Add(d)
+	} else if t, err = time.Parse(time.RFC3339, s); err != nil {
+		if t, err = parseDate(s, now); err != nil {
+			return time.Time{}, fmt.Errorf("bad --expires %q (want 12h, 30d, YYYY-MM-DD or an RFC 3339 time)", s)
+		}
+	}
+	if !t.After(now) {
+		return time.Time{}, fmt.Errorf("--expires %q is in the past", s)
+	}
+	return t.UTC(), nil
+}
+
+// parseDays reads "30d" or "2w", which time.ParseDuration does not know.
+func parseDays(s string) (time.Duration, bool) {
+	if s == "" {
+		return 0, false
+	}
+	var unit time.Duration
+	switch s[len(s)-1] {
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	default:
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(s[:len(s)-1], "+"))
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
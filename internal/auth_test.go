@@ -0,0 +1,29 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+// Days and weeks count from now, like hours; dates mean local midnight.
+func TestParseExpires(t *testing.T) {
+	now := time.Date(2026, 3, 5, 15, 30, 0, 0, time.UTC)
+	for in, want := range map[string]time.Time{
+		"12h":                  now.Add(12 * time.Hour),
+		"30d":                  now.Add(30 * 24 * time.Hour),
+		"+1d":                  now.Add(24 * time.Hour),
+		"2w":                   now.Add(14 * 24 * time.Hour),
+		"2026-12-31T10:00:00Z": time.Date(2026, 12, 31, 10, 0, 0, 0, time.UTC),
+		"2026-12-31":           time.Date(2026, 12, 31, 0, 0, 0, 0, time.Local).UTC(),
+	} {
+		got, err := parseExpires(in, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("parseExpires(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"0d", "-1d", "2026-01-01", "soon", "d"} {
+		if _, err := parseExpires(in, now); err == nil {
+			t.Errorf("parseExpires(%q) accepted", in)
+		}
+	}
+}
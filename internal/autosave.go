@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// saveDebounce groups bursts of edits (e.g. holding J) into one write.
+const saveDebounce = 500 * time.Millisecond
+
+// saveDueMsg fires when the debounce for the save scheduled at rev expires.
+type saveDueMsg struct{ rev int }
+
+// savedMsg reports a finished save: items is what was written.
+type savedMsg struct {
+	items []Item
+	stamp fileStamp
+	err   error
+}
+
+// touch records a local mutation; Update schedules a save for it.
+func (m *modelTUI) touch() {
+	m.changed = true
+	m.rev++
+}
+
+func (m *modelTUI) scheduleSave() tea.Cmd {
+	m.saveSeq = m.rev
+	m.saveState = "saving…"
+	m.refreshStatus()
+	rev := m.rev
+	return tea.Tick(saveDebounce, func(time.Time) tea.Msg { return saveDueMsg{rev: rev} })
+}
+
+// startSave writes the list unless a newer edit restarted the debounce or
+// a save is already running (finishSave picks up what it missed).
+func (m *modelTUI) startSave(msg saveDueMsg) tea.Cmd {
+	if msg.rev != m.saveSeq || m.saving || !m.changed {
+		return nil
+	}
+	m.saving = true
+	return saveItems(m.gate, m.base, m.currentItems())
+}
+
+// saveGate orders background saves against the final save on quit: the
+// final save waits for one in flight, and none starts after it.
+type saveGate struct {
+	mu     sync.Mutex
+	closed bool
+}
+
+// close waits for a running save and stops later ones; the caller does the
+// final save, then calls mu.Unlock.
+func (g *saveGate) close() {
+	g.mu.Lock()
+	g.closed = true
+}
+
+// saveItems merges local into whatever is on disk now and writes the result.
+func saveItems(g *saveGate, base, local []Item) tea.Cmd {
+	return func() tea.Msg {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if g.closed {
+			return savedMsg{err: errors.New("quitting")}
+		}
+		disk, err := Load()
+		if err != nil {
+			return savedMsg{err: err}
+		}
+		out := mergeItems(base, local, disk)
+		if err := SaveTrashing(disk, out); err != nil {
+			return savedMsg{err: err}
+		}
+		st, err := storeStamp()
+		return savedMsg{items: out, stamp: st, err: err}
+	}
+}
+
+func (m *modelTUI) finishSave(msg savedMsg) tea.Cmd {
+	m.saving = false
+	if msg.err != nil {
+		// keep changed set: the next edit or quitting retries
+		m.saveState = "error: " + msg.err.Error()
+		m.refreshStatus()
+		return nil
+	}
+	// what we wrote is the new disk state; folding it in picks up external
+	// changes the save merged and keeps edits made while it ran
+	m.stamp = msg.stamp
+	cmd := m.applyExternal(msg.items)
+	if m.changed {
+		return tea.Batch(cmd, m.scheduleSave())
+	}
+	m.saveState = "saved"
+	m.refreshStatus()
+	return cmd
+}
@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"os"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Saves replace the data file in one step and leave no temporary files.
+func TestSaveIsAtomic(t *testing.T) {
+	dir := inTempDir(t)
+	if err := Save([]Item{newItem("a")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save([]Item{newItem("b")}); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if e.Name() != dataFileName && e.Name() != trashFileName {
+			t.Errorf("stray file %s", e.Name())
+		}
+	}
+}
+
+// Once the final save on quit has closed the gate, a background save that
+// starts late must not write.
+func TestSaveGateStopsLateSaves(t *testing.T) {
+	inTempDir(t)
+	g := &saveGate{}
+	g.close()
+	done := make(chan savedMsg)
+	go func() { done <- saveItems(g, nil, []Item{newItem("late")})().(savedMsg) }()
+	if err := Save([]Item{newItem("final")}); err != nil {
+		t.Fatal(err)
+	}
+	g.mu.Unlock()
+	if msg := <-done; msg.err == nil {
+		t.Fatal("late save ran after the final one")
+	}
+	items, _ := Load()
+	if len(items) != 1 || items[0].Title != "final" {
+		t.Fatalf("items = %+v, want only the final save", items)
+	}
+}
+
+// A live sync holds the gate like a save: once the final save closed it,
+// a late pull must not reach the server or touch the data file.
+func TestSaveGateStopsLateLiveSync(t *testing.T) {
+	inTempDir(t)
+	f, ts := newFakeSync(t)
+	c, _ := testClient(ts)
+	if err := Save([]Item{newItem("final")}); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := storeStamp()
+	g := &saveGate{}
+	g.close()
+	l := &liveSync{client: c, list: defaultListName}
+	done := make(chan tea.Msg)
+	go func() { done <- l.run(g)() }()
+	g.mu.Unlock()
+	if msg := (<-done).(liveSyncedMsg); msg.err == nil {
+		t.Fatal("live sync ran after the final save")
+	}
+	if after, _ := storeStamp(); after != before || f.requests != 0 {
+		t.Fatal("late live sync wrote the data file")
+	}
+}
@@ -0,0 +1,233 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The board is a second view over modelTUI's list: one column per Status.
+// It edits the same list items, so saving and undo work exactly as in the
+// list view.
+
+// parseWIP reads limits like "doing=3,todo=10".
+func parseWIP(s string) (map[Status]int, error) {
+	wip := map[Status]int{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, val, found := strings.Cut(part, "=")
+		st, ok := parseStatus(strings.TrimSpace(name))
+		if !found || !ok {
+			return nil, fmt.Errorf("bad wip limit %q (want <todo|doing|done>=<n>)", part)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(val))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("bad wip limit %q: not a non-negative number", part)
+		}
+		wip[st] = n
+	}
+	return wip, nil
+}
+
+// boardColumns groups global list indices by status, keeping list order.
+func (m modelTUI) boardColumns() [][]int {
+	cols := make([][]int, len(statuses))
+	for i, it := range m.list.Items() {
+		li, ok := it.(listItem)
+		if !ok {
+			continue
+		}
+		for c, st := range statuses {
+			if li.Status == st {
+				cols[c] = append(cols[c], i)
+			}
+		}
+	}
+	return cols
+}
+
+// clampBoard keeps the board cursor inside the current column.
+func (m *modelTUI) clampBoard(cols [][]int) {
+	if m.boardCol < 0 {
+		m.boardCol = 0
+	}
+	if m.boardCol >= len(cols) {
+		m.boardCol = len(cols) - 1
+	}
+	if n := len(cols[m.boardCol]); m.boardRow >= n {
+		m.boardRow = n - 1
+	}
+	if m.boardRow < 0 {
+		m.boardRow = 0
+	}
+}
+
+func (m modelTUI) updateBoard(msg tea.Msg) (tea.Model, tea.Cmd) {
+	km, isKey := msg.(tea.KeyMsg)
+	if !isKey {
+		return m, nil
+	}
+	m.boardMsg = ""
+	cols := m.boardColumns()
+	switch {
+	case key.Matches(km, m.keys.Quit), km.String() == "ctrl+c":
+		return m, tea.Quit
+	case key.Matches(km, m.keys.Board), km.String() == "esc":
+		m.board = false
+	case key.Matches(km, m.keys.CursorLeft):
+		m.boardCol--
+	case key.Matches(km, m.keys.CursorRight):
+		m.boardCol++
+	case key.Matches(km, m.keys.CursorUp):
+		m.boardRow--
+	case key.Matches(km, m.keys.CursorDown):
+		m.boardRow++
+	case key.Matches(km, m.keys.MoveLeft):
+		return m, m.boardMove(cols, -1)
+	case key.Matches(km, m.keys.MoveRight):
+		return m, m.boardMove(cols, 1)
+	case key.Matches(km, m.keys.Undo):
+		return m, m.undoLast()
+	}
+	m.clampBoard(cols)
+	return m, nil
+}
+
+// boardMove moves the card under the cursor to the neighbouring column,
+// refusing when that column is at its WIP limit.
+func (m *modelTUI) boardMove(cols [][]int, delta int) tea.Cmd {
+	m.clampBoard(cols)
+	col := cols[m.boardCol]
+	dst := m.boardCol + delta
+	if len(col) == 0 || dst < 0 || dst >= len(statuses) {
+		return nil
+	}
+	st := statuses[dst]
+	if lim := m.wip[st]; lim > 0 && len(cols[dst]) >= lim {
+		m.boardMsg = fmt.Sprintf("WIP limit reached for %s (%d)", st, lim)
+		return nil
+	}
+	i := col[m.boardRow]
+	li, ok := m.list.Items()[i].(listItem)
+	if !ok {
+		return nil
+	}
+	m.snapshot()
+	li.setStatus(st)
+	cmd := m.list.SetItem(i, li)
+	m.touch()
+
+	// follow the card into its new column
+	m.boardCol = dst
+	m.boardRow = 0
+	for _, j := range cols[dst] {
+		if j < i {
+			m.boardRow++
+		}
+	}
+	return cmd
+}
+
+func (m modelTUI) boardView(w, h int) string {
+	cols := m.boardColumns()
+	m.clampBoard(cols)
+
+	done, total := len(cols[len(cols)-1]), len(m.list.Items())
+	header := fmt.Sprintf("%s   %s %d  %s %d  %s %d",
+		titleStyle.Render("Board"),
+		successStyle.Render("✔"), done,
+		pendingStyle.Render("•"), total-done,
+		accentStyle.Render("Total"), total,
+	)
+	if m.saveState != "" {
+		header += "   " + mutedStyle.Render(m.saveState)
+	}
+
+	// w and h are the panel's inner size; each column adds a 2-cell border
+	colW := w/len(statuses) - 2
+	if colW < 12 {
+		colW = 12
+	}
+	rows := h - 8 // header, column borders and titles, help, notice
+	if rows < 1 {
+		rows = 1
+	}
+
+	items := m.list.Items()
+	rendered := make([]string, 0, len(cols))
+	for c, st := range statuses {
+		count := fmt.Sprintf("%d", len(cols[c]))
+		countStyle := mutedStyle
+		if lim := m.wip[st]; lim > 0 {
+			count = fmt.Sprintf("%d/%d", len(cols[c]), lim)
+			if len(cols[c]) > lim {
+				countStyle = errorStyle
+			}
+		}
+		name := strings.ToUpper(string(st[:1])) + string(st[1:])
+		lines := []string{titleStyle.Render(name) + " " + countStyle.Render(count), ""}
+
+		// scroll so the cursor card stays visible
+		off := 0
+		if c == m.boardCol && m.boardRow >= rows {
+			off = m.boardRow - rows + 1
+		}
+		for r := off; r < len(cols[c]) && r < off+rows; r++ {
+			li, _ := items[cols[c][r]].(listItem)
+			text := truncate(li.Text, colW-2)
+			if li.Done {
+				text = doneStyle.Render(text)
+			}
+			prefix := "  "
+			if c == m.boardCol && r == m.boardRow {
+				prefix = selectedStyle.Render("> ")
+			}
+			lines = append(lines, prefix+text)
+		}
+
+		border := lipgloss.Color("8")
+		if c == m.boardCol {
+			border = lipgloss.Color("12")
+		}
+		box := lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(border).
+			Width(colW).
+			Padding(0, 1)
+		rendered = append(rendered, box.Render(strings.Join(lines, "\n")))
+	}
+
+	k := m.keys
+	help := helpStyle.Render(strings.Join([]string{
+		helpEntry("column", k.CursorLeft, k.CursorRight),
+		helpEntry("card", k.CursorUp, k.CursorDown),
+		helpEntry("move card", k.MoveLeft, k.MoveRight),
+		helpEntry("undo", k.Undo),
+		helpEntry("list", k.Board),
+		helpEntry("quit", k.Quit),
+	}, " • "))
+	out := []string{header, "", lipgloss.JoinHorizontal(lipgloss.Top, rendered...), help}
+	if m.boardMsg != "" {
+		out = append(out, errorStyle.Render(m.boardMsg))
+	}
+	return strings.Join(out, "\n")
+}
+
+// truncate shortens s to at most n runes, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if n <= 0 || len(r) <= n {
+		return s
+	}
+	if n == 1 {
+		return "…"
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The calendar is a month grid over the list's items, placed by their due
+// and scheduled dates. Like the board, it reads m.list directly.
+
+// agenda returns the pending items of the list placed on their days.
+func (m modelTUI) agenda() []agendaEntry {
+	return agendaEntries(m.currentItems())
+}
+
+func (m modelTUI) updateCalendar(msg tea.Msg) (tea.Model, tea.Cmd) {
+	km, isKey := msg.(tea.KeyMsg)
+	if !isKey {
+		return m, nil
+	}
+	switch {
+	case key.Matches(km, m.keys.Quit), km.String() == "ctrl+c":
+		return m, tea.Quit
+	case km.String() == "esc" && m.calDrill:
+		m.calDrill = false
+	case key.Matches(km, m.keys.Calendar), km.String() == "esc":
+		m.cal = false
+		m.calDrill = false
+	case km.String() == "enter":
+		m.calDrill = !m.calDrill
+	case key.Matches(km, m.keys.CursorLeft):
+		m.calDay = m.calDay.AddDate(0, 0, -1)
+	case key.Matches(km, m.keys.CursorRight):
+		m.calDay = m.calDay.AddDate(0, 0, 1)
+	case key.Matches(km, m.keys.CursorUp):
+		m.calDay = m.calDay.AddDate(0, 0, -7)
+	case key.Matches(km, m.keys.CursorDown):
+		m.calDay = m.calDay.AddDate(0, 0, 7)
+	case key.Matches(km, m.keys.PrevPage):
+		m.calDay = m.calDay.AddDate(0, -1, 0)
+	case key.Matches(km, m.keys.NextPage):
+		m.calDay = m.calDay.AddDate(0, 1, 0)
+	case key.Matches(km, m.keys.Today):
+		m.calDay = dayStart(time.Now())
+	}
+	return m, nil
+}
+
+func (m modelTUI) calendarView() string {
+	const cellW = 6
+	today := dayStart(time.Now())
+	sel := m.calDay
+	first := time.Date(sel.Year(), sel.Month(), 1, 0, 0, 0, 0, time.Local)
+	entries := m.agenda()
+	counts := map[time.Time]int{}
+	for _, e := range entries {
+		counts[e.Day]++
+	}
+
+	title := titleStyle.Render(first.Format("January 2006"))
+	if m.saveState != "" {
+		title += "   " + mutedStyle.Render(m.saveState)
+	}
+	lines := []string{title, ""}
+
+	cell := lipgloss.NewStyle().Width(cellW)
+	var head []string
+	for _, d := range []string{"Mo", "Tu", "We", "Th", "Fr", "Sa", "Su"} {
+		head = append(head, cell.Render(mutedStyle.Render(d)))
+	}
+	lines = append(lines, strings.Join(head, ""))
+
+	// Monday-first grid: pad the first week
+	offset := (int(first.Weekday()) + 6) % 7
+	var row []string
+	for i := 0; i < offset; i++ {
+		row = append(row, cell.Render(""))
+	}
+	for d := first; d.Month() == first.Month(); d = d.AddDate(0, 0, 1) {
+		num := fmt.Sprintf("%2d", d.Day())
+		switch {
+		case d.Equal(sel):
+			num = selectedStyle.Render(num)
+		case d.Equal(today):
+			num = accentStyle.Render(num)
+		}
+		txt := num
+		if n := counts[d]; n > 0 {
+			st := pendingStyle
+			if d.Before(today) {
+				st = errorStyle
+			}
+			txt += st.Render(fmt.Sprintf("·%d", n))
+		}
+		row = append(row, cell.Render(txt))
+		if len(row) == 7 {
+			lines = append(lines, strings.Join(row, ""))
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		lines = append(lines, strings.Join(row, ""))
+	}
+
+	lines = append(lines, "")
+	if m.calDrill {
+		lines = append(lines, titleStyle.Render(sel.Format("Monday 02 January")))
+		n := 0
+		for _, e := range entries {
+			if !e.Day.Equal(sel) {
+				continue
+			}
+			n++
+			lines = append(lines, fmt.Sprintf("  %s %s  %s", mutedStyle.Render(boxUnchecked), e.Item.Title, mutedStyle.Render(e.Kind)))
+		}
+		if n == 0 {
+			lines = append(lines, mutedStyle.Render("  nothing planned"))
+		}
+		lines = append(lines, "")
+	}
+	k := m.keys
+	lines = append(lines, helpStyle.Render(strings.Join([]string{
+		helpEntry("day", k.CursorLeft, k.CursorRight, k.CursorUp, k.CursorDown),
+		helpEntry("month", k.PrevPage, k.NextPage),
+		helpEntry("today", k.Today),
+		"enter show day",
+		helpEntry("list", k.Calendar),
+		helpEntry("quit", k.Quit),
+	}, " • ")))
+	return strings.Join(lines, "\n")
+}
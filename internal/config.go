@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const configFileName = "config.json"
+
+// Config is the user configuration read from ~/.tada/config.json. Every
+// field is optional; a missing file means defaults everywhere.
+type Config struct {
+	Keymap KeymapConfig `json:"keymap"`
+	Trash  TrashConfig  `json:"trash"`
+	TUI    TUIConfig    `json:"tui"`
+	Focus  FocusConfig  `json:"focus"`
+	Sync   SyncConfig   `json:"sync"`
+	Auth   AuthConfig   `json:"auth"`
+}
+
+// KeymapConfig picks a keybinding preset and overrides single actions.
+type KeymapConfig struct {
+	Preset   string              `json:"preset"`   // "default" | "vim" | "emacs"
+	Bindings map[string][]string `json:"bindings"` // action -> keys, e.g. "add": ["o"]
+}
+
+func configPath() (string, error) {
+	dir, err := credsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configFileName), nil
+}
+
+// LoadConfig reads the config file. TADA_CONFIG overrides its location.
+func LoadConfig() (Config, error) {
+	p := os.Getenv("TADA_CONFIG")
+	if p == "" {
+		var err error
+		if p, err = configPath(); err != nil {
+			return Config{}, err
+		}
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Config{}, nil
+		}
+		return Config{}, fmt.Errorf("read config: %w", err)
+	}
+	var c Config
+	if err := json.Unmarshal(b, &c); err != nil {
+		return Config{}, fmt.Errorf("parse config %s: %w", p, err)
+	}
+	return c, nil
+}
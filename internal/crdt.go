@@ -0,0 +1,468 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Two copies of the data file that diverged (two laptops, two git branches)
+// merge without a common ancestor, and always to the same result:
+//
+//   - every field is a last-writer-wins register: saveAll stamps the fields
+//     a save changed (Item.Stamps) and the merge keeps the newer value;
+//   - items form an add-wins set: a deleted item leaves a tombstone with
+//     the stamps it had, and a copy edited after those stamps survives it;
+//   - manual order is a fractional index (Item.Pos) that only changes for
+//     the items that moved.
+//
+// Ties (equal stamps, e.g. files written before stamps existed) go to the
+// larger value, so the merge does not depend on which side is which.
+
+const (
+	// tombstoneTTL is how long a deleted item is remembered for merges with
+	// copies that have not seen the delete yet.
+	tombstoneTTL = 90 * 24 * time.Hour
+
+	// posDigits are the digits of position keys, in byte order.
+	posDigits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+	// addedStamp is the stamp key for when an item was (re)added.
+	addedStamp = "id"
+)
+
+// crdtRegisters groups Item's JSON fields into registers. Fields that only
+// make sense together (done, status and completion time) share one, so a
+// merge never mixes them. The first name is the register's stamp key.
+var crdtRegisters = [][]string{
+	{"title"},
+	{"list"},
+	{"done", "status", "completed_at"},
+	{"due"},
+	{"scheduled"},
+	{"focus"},
+	{"tags"},
+	{"priority"},
+	{"created_at"},
+	{"project"},
+	{"estimate_minutes"},
+	{"time"},
+	{"pos"},
+}
+
+// tombstone records a deleted item in the data file.
+type tombstone struct {
+	ID      string           `json:"id"`
+	List    string           `json:"list,omitempty"`
+	Deleted time.Time        `json:"deleted"`
+	Stamps  map[string]int64 `json:"stamps,omitempty"` // the item's stamps when deleted
+}
+
+func itemFields(it Item) map[string]json.RawMessage {
+	b, _ := json.Marshal(it)
+	var f map[string]json.RawMessage
+	json.Unmarshal(b, &f)
+	return f
+}
+
+// registerValue encodes a register's fields for comparison.
+func registerValue(f map[string]json.RawMessage, reg []string) []byte {
+	var b bytes.Buffer
+	for _, k := range reg {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.Write(f[k])
+		b.WriteByte(';')
+	}
+	return b.Bytes()
+}
+
+// maxStamps combines two sets of stamps, keeping the newer of each.
+func maxStamps(a, b map[string]int64) map[string]int64 {
+	out := maps.Clone(a)
+	if out == nil {
+		out = map[string]int64{}
+	}
+	for k, v := range b {
+		out[k] = max(out[k], v)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func maxStamp(stamps map[string]int64) int64 {
+	var m int64
+	for _, s := range stamps {
+		m = max(m, s)
+	}
+	return m
+}
+
+// ---------------------------------------------------
+// stamping saves
+// ---------------------------------------------------
+
+// stampChanges sets the stamps of items against what the file held before
+// (prev, keyed by ID). A changed register gets now, or the stamp the item
+// brought along if that is newer (a value merged in from elsewhere keeps
+// its time). Items back from the trash count as added now.
+func stampChanges(prev map[string]Item, dead map[string]tombstone, items []Item, now int64) {
+	for i := range items {
+		it := &items[i]
+		p, had := prev[it.ID]
+		cur := itemFields(*it)
+		stamps := maps.Clone(p.Stamps)
+		if stamps == nil {
+			stamps = map[string]int64{}
+		}
+		if !had {
+			_, readded := dead[it.ID]
+			if s := it.Stamps[addedStamp]; s > 0 && !readded {
+				stamps[addedStamp] = s
+			} else {
+				stamps[addedStamp] = now
+			}
+		}
+		old := itemFields(p)
+		for _, reg := range crdtRegisters {
+			k := reg[0]
+			theirs := it.Stamps[k]
+			switch {
+			case had && bytes.Equal(registerValue(old, reg), registerValue(cur, reg)):
+				stamps[k] = max(stamps[k], theirs)
+			case theirs > stamps[k]:
+				stamps[k] = theirs
+			case !had && theirs == 0 && len(registerValue(cur, reg)) == len(registerValue(nil, reg)):
+				// a new item's empty field has no edit to stamp
+			default:
+				stamps[k] = max(now, stamps[k]+1)
+			}
+		}
+		maps.DeleteFunc(stamps, func(_ string, v int64) bool { return v == 0 })
+		it.Stamps = stamps
+		if len(stamps) == 0 {
+			it.Stamps = nil
+		}
+	}
+}
+
+// buryRemoved returns the tombstones to write: earlier ones still within
+// tombstoneTTL and not back in items, plus one for each item gone since prev.
+func buryRemoved(prev []Item, dead []tombstone, items []Item, now time.Time) []tombstone {
+	kept := itemsByID(items)
+	var out []tombstone
+	for _, t := range dead {
+		if _, back := kept[t.ID]; !back && now.Sub(t.Deleted) < tombstoneTTL {
+			out = append(out, t)
+		}
+	}
+	for _, it := range prev {
+		if _, has := kept[it.ID]; !has {
+			out = append(out, tombstone{ID: it.ID, List: it.List, Deleted: now, Stamps: it.Stamps})
+		}
+	}
+	return out
+}
+
+// ---------------------------------------------------
+// fractional positions
+// ---------------------------------------------------
+
+// keyBetween returns a position key sorting strictly between a and b; an
+// empty a means before everything and an empty b after everything. Keys
+// never end in the lowest digit, so there is always room before a key.
+func keyBetween(a, b string) string {
+	digit := func(s string, i int) int {
+		if i < len(s) {
+			return strings.IndexByte(posDigits, s[i])
+		}
+		return 0
+	}
+	n := 0
+	if b != "" {
+		for n < len(b) && digit(a, n) == digit(b, n) {
+			n++
+		}
+	}
+	prefix := b[:n]
+	da, db := digit(a, n), len(posDigits)
+	if n < len(b) {
+		db = digit(b, n)
+	}
+	if db-da > 1 {
+		// step by one digit at either end: appending (or prepending) is by
+		// far the most common insert and halving would grow keys quickly
+		switch {
+		case a == "" && b == "":
+			return string(posDigits[db/2])
+		case b == "":
+			return string(posDigits[da+1])
+		case a == "":
+			return prefix + string(posDigits[db-1])
+		}
+		return prefix + string(posDigits[(da+db)/2])
+	}
+	if n+1 < len(b) {
+		// b goes on after this digit, so the digit alone sorts between
+		return prefix + string(posDigits[db])
+	}
+	rest := ""
+	if n+1 < len(a) {
+		rest = a[n+1:]
+	}
+	return prefix + string(posDigits[da]) + keyBetween(rest, "")
+}
+
+// assignPositions gives items increasing Pos keys in slice order. The
+// longest run of keys already in order is kept, so a move or insert only
+// changes the keys of the items involved.
+func assignPositions(items []Item) {
+	// longest strictly increasing subsequence of existing keys
+	var tails []int // index of the smallest tail of each run length
+	prev := make([]int, len(items))
+	for i, it := range items {
+		prev[i] = -1
+		if it.Pos == "" {
+			continue
+		}
+		l := sort.Search(len(tails), func(j int) bool { return items[tails[j]].Pos >= it.Pos })
+		if l > 0 {
+			prev[i] = tails[l-1]
+		}
+		if l == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[l] = i
+		}
+	}
+	keep := make([]bool, len(items))
+	if len(tails) > 0 {
+		for i := tails[len(tails)-1]; i >= 0; i = prev[i] {
+			keep[i] = true
+		}
+	}
+
+	next := make([]string, len(items)) // key of the next kept item
+	hi := ""
+	for i := len(items) - 1; i >= 0; i-- {
+		next[i] = hi
+		if keep[i] {
+			hi = items[i].Pos
+		}
+	}
+	lo := ""
+	for i := range items {
+		if !keep[i] {
+			items[i].Pos = keyBetween(lo, next[i])
+		}
+		lo = items[i].Pos
+	}
+}
+
+// positionLists assigns positions within each list, since order is only
+// meaningful between items of the same list.
+func positionLists(items []Item) {
+	byList := map[string][]int{}
+	for i, it := range items {
+		byList[itemList(it)] = append(byList[itemList(it)], i)
+	}
+	for _, idx := range byList {
+		sub := make([]Item, len(idx))
+		for k, i := range idx {
+			sub[k] = items[i]
+		}
+		assignPositions(sub)
+		for k, i := range idx {
+			items[i].Pos = sub[k].Pos
+		}
+	}
+}
+
+// ---------------------------------------------------
+// merging replicas
+// ---------------------------------------------------
+
+// mergeItem merges two copies of one item register by register. fb holds
+// the fields of a common ancestor, if known: with equal stamps (files saved
+// before stamps existed) the side that changed a register from it wins.
+func mergeItem(fb map[string]json.RawMessage, x, y Item) Item {
+	fx, fy := itemFields(x), itemFields(y)
+	out := map[string]json.RawMessage{}
+	stamps := map[string]int64{}
+	for _, reg := range crdtRegisters {
+		k := reg[0]
+		sx, sy := x.Stamps[k], y.Stamps[k]
+		vx, vy := registerValue(fx, reg), registerValue(fy, reg)
+		from := fx
+		switch {
+		case sy != sx:
+			if sy > sx {
+				from = fy
+			}
+		case fb != nil && bytes.Equal(vx, registerValue(fb, reg)):
+			from = fy
+		case fb != nil && bytes.Equal(vy, registerValue(fb, reg)):
+		case bytes.Compare(vy, vx) > 0:
+			from = fy
+		}
+		for _, f := range reg {
+			if v, has := from[f]; has {
+				out[f] = v
+			}
+		}
+		if s := max(sx, sy); s > 0 {
+			stamps[k] = s
+		}
+	}
+	if s := max(x.Stamps[addedStamp], y.Stamps[addedStamp]); s > 0 {
+		stamps[addedStamp] = s
+	}
+	b, _ := json.Marshal(out)
+	var it Item
+	json.Unmarshal(b, &it)
+	it.ID, it.Rev = x.ID, max(x.Rev, y.Rev)
+	it.Stamps = stamps
+	if len(stamps) == 0 {
+		it.Stamps = nil
+	}
+	it.normalize()
+	return it
+}
+
+// mergeReplicas merges two copies of a data file. The result is the same
+// whichever copy comes first and merging it again with either is a no-op.
+// base is their common ancestor when known (see mergeItem); items it has
+// that a side dropped without a tombstone count as deleted on that side.
+func mergeReplicas(base, a, b []Item, deadA, deadB []tombstone) ([]Item, []tombstone) {
+	deadA = slices.Concat(deadA, missingFrom(base, a, deadA))
+	deadB = slices.Concat(deadB, missingFrom(base, b, deadB))
+	dead := map[string]tombstone{}
+	for _, t := range slices.Concat(deadA, deadB) {
+		d, has := dead[t.ID]
+		if !has {
+			dead[t.ID] = t
+			continue
+		}
+		later := t
+		if d.Deleted.After(t.Deleted) || (d.Deleted.Equal(t.Deleted) && d.List > t.List) {
+			later = d
+		}
+		later.Stamps = maxStamps(d.Stamps, t.Stamps)
+		dead[t.ID] = later
+	}
+
+	baseBy := itemsByID(base)
+	byID := itemsByID(a)
+	for _, it := range b {
+		if x, has := byID[it.ID]; has {
+			var fb map[string]json.RawMessage
+			if o, known := baseBy[it.ID]; known {
+				fb = itemFields(o)
+			}
+			byID[it.ID] = mergeItem(fb, x, it)
+		} else {
+			byID[it.ID] = it
+		}
+	}
+	var live []Item
+	for id, it := range byID {
+		if t, gone := dead[id]; gone {
+			o, known := baseBy[id]
+			edited := maxStamp(it.Stamps) > maxStamp(t.Stamps) || (known && !sameItem(it, o))
+			if !edited {
+				continue // the delete saw every edit
+			}
+			delete(dead, id) // edited after the delete: add wins
+		}
+		live = append(live, it)
+	}
+	sort.Slice(live, func(i, j int) bool {
+		if live[i].Pos != live[j].Pos {
+			return live[i].Pos < live[j].Pos
+		}
+		return live[i].ID < live[j].ID
+	})
+	var stones []tombstone
+	for _, t := range dead {
+		stones = append(stones, t)
+	}
+	sort.Slice(stones, func(i, j int) bool { return stones[i].ID < stones[j].ID })
+	return live, stones
+}
+
+// missingFrom returns tombstones for base items that side dropped without
+// leaving one, dated by their last stamp so the result stays deterministic.
+func missingFrom(base, side []Item, dead []tombstone) []tombstone {
+	have := itemsByID(side)
+	for _, t := range dead {
+		have[t.ID] = Item{}
+	}
+	var out []tombstone
+	for _, it := range base {
+		if _, has := have[it.ID]; !has {
+			out = append(out, tombstone{ID: it.ID, List: it.List, Deleted: time.UnixMilli(maxStamp(it.Stamps)).UTC(), Stamps: it.Stamps})
+		}
+	}
+	return out
+}
+
+// ---------------------------------------------------
+// merge command
+// ---------------------------------------------------
+
+const mergeUsage = "usage: todo merge <a.json> <b.json> [-o file]"
+
+// readReplica reads a data file for merging. Files from before positions
+// were recorded get them from their current order, reusing the ancestor's
+// where it has the item so that only moved items differ from it.
+func readReplica(path string, base []Item) ([]Item, []tombstone, error) {
+	live, dead, err := readItems(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	baseBy := itemsByID(base)
+	for i := range live {
+		if o, known := baseBy[live[i].ID]; known && live[i].Pos == "" {
+			live[i].Pos = o.Pos
+		}
+	}
+	positionLists(live)
+	return live, dead, nil
+}
+
+func doMerge(a, b, out string) int {
+	la, da, err := readReplica(a, nil)
+	if err != nil {
+		fail("merge: " + a + ": " + err.Error())
+		return 1
+	}
+	lb, db, err := readReplica(b, nil)
+	if err != nil {
+		fail("merge: " + b + ": " + err.Error())
+		return 1
+	}
+	live, dead := mergeReplicas(nil, la, lb, da, db)
+	data, err := encodeItems(live, dead)
+	if err != nil {
+		fail("merge: " + err.Error())
+		return 1
+	}
+	if out == "" || out == "-" {
+		os.Stdout.Write(data)
+		return 0
+	}
+	if err := writeFileAtomic(out, data, 0o644); err != nil {
+		fail("merge: " + err.Error())
+		return 1
+	}
+	ok(fmt.Sprintf("merged %d items (%d deleted) into %s", len(live), len(dead), out))
+	return 0
+}
@@ -0,0 +1,530 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// `todo serve` streams item changes as server-sent events on GET /events:
+//
+//	id: <epoch>-<seq>
+//	event: create|update|delete
+//	data: {"seq": 7, "type": "update", "id": "…", "rev": 3, "item": {…}}
+//
+// Sequence numbers grow by one per change. A client that reconnects with
+// Last-Event-ID gets what it missed from a backlog of recent events; if
+// that is no longer possible (too far behind, or the server restarted and
+// the epoch changed) it gets a "reset" event and should refetch /items.
+
+const (
+	eventBacklog = 1000
+	eventPing    = 15 * time.Second
+
+	liveRetryMin = time.Second
+	liveRetryMax = 30 * time.Second
+)
+
+// itemEvent is one change in the stream. Item is absent for deletes.
+type itemEvent struct {
+	Seq  int64  `json:"seq"`
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Rev  int    `json:"rev"`
+	Item *Item  `json:"item,omitempty"`
+}
+
+// eventHub numbers events and keeps the backlog. Subscribers are only
+// woken up and read the backlog themselves, so a slow client never blocks
+// a write; if it falls behind the backlog it gets a reset.
+type eventHub struct {
+	mu      sync.Mutex
+	epoch   string // distinguishes this server run in event IDs
+	seq     int64
+	backlog []itemEvent
+	subs    map[chan struct{}]bool
+
+	closeOnce sync.Once
+	closed    chan struct{} // closed on shutdown to end open streams
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{
+		epoch:  strconv.FormatInt(time.Now().UnixNano(), 36),
+		subs:   map[chan struct{}]bool{},
+		closed: make(chan struct{}),
+	}
+}
+
+func (h *eventHub) eventID(seq int64) string { return h.epoch + "-" + strconv.FormatInt(seq, 10) }
+
+// parseID returns the sequence number in an event ID from this server run.
+func (h *eventHub) parseID(id string) (int64, bool) {
+	epoch, n, found := strings.Cut(id, "-")
+	if !found || epoch != h.epoch {
+		return 0, false
+	}
+	seq, err := strconv.ParseInt(n, 10, 64)
+	return seq, err == nil && seq >= 0
+}
+
+func (h *eventHub) publish(evs []itemEvent) {
+	if len(evs) == 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, ev := range evs {
+		h.seq++
+		ev.Seq = h.seq
+		h.backlog = append(h.backlog, ev)
+	}
+	if n := len(h.backlog) - eventBacklog; n > 0 {
+		h.backlog = slices.Delete(h.backlog, 0, n)
+	}
+	for c := range h.subs {
+		select {
+		case c <- struct{}{}:
+		default: // already has a pending wake-up
+		}
+	}
+}
+
+// since returns the events after seq. ok is false if some of them have
+// been dropped from the backlog; head is the latest sequence number.
+func (h *eventHub) since(seq int64) (evs []itemEvent, head int64, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if seq > h.seq || (len(h.backlog) > 0 && h.backlog[0].Seq > seq+1) {
+		return nil, h.seq, false
+	}
+	for _, ev := range h.backlog {
+		if ev.Seq > seq {
+			evs = append(evs, ev)
+		}
+	}
+	return evs, h.seq, true
+}
+
+func (h *eventHub) head() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.seq
+}
+
+func (h *eventHub) subscribe() chan struct{} {
+	c := make(chan struct{}, 1)
+	h.mu.Lock()
+	h.subs[c] = true
+	h.mu.Unlock()
+	return c
+}
+
+func (h *eventHub) unsubscribe(c chan struct{}) {
+	h.mu.Lock()
+	delete(h.subs, c)
+	h.mu.Unlock()
+}
+
+func (h *eventHub) close() { h.closeOnce.Do(func() { close(h.closed) }) }
+
+// diffEvents describes how items changed since old (keyed by ID): creates
+// and updates in list order, then deletes.
+func diffEvents(old map[string]Item, items []Item) []itemEvent {
+	var evs []itemEvent
+	seen := make(map[string]bool, len(items))
+	for _, it := range items {
+		seen[it.ID] = true
+		o, known := old[it.ID]
+		if known && sameItem(o, it) {
+			continue
+		}
+		typ := "update"
+		if !known {
+			typ = "create"
+		}
+		cp := withoutList(it)
+		evs = append(evs, itemEvent{Type: typ, ID: it.ID, Rev: it.Rev, Item: &cp})
+	}
+	var gone []string
+	for id := range old {
+		if !seen[id] {
+			gone = append(gone, id)
+		}
+	}
+	slices.Sort(gone)
+	for _, id := range gone {
+		evs = append(evs, itemEvent{Type: "delete", ID: id, Rev: old[id].Rev})
+	}
+	return evs
+}
+
+// ---------------------------------------------------
+// server side
+// ---------------------------------------------------
+
+func writeEvent(w http.ResponseWriter, id, typ string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", id, typ, b)
+	return err
+}
+
+// streamEvents serves GET /events. Resume with the Last-Event-ID header
+// (sent by EventSource on reconnect) or ?last_event_id=.
+func (s *server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	fl, canFlush := w.(http.Flusher)
+	if !canFlush {
+		writeError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+	h := s.events
+	wake := h.subscribe()
+	defer h.unsubscribe(wake)
+
+	last := r.Header.Get("Last-Event-ID")
+	if last == "" {
+		last = r.URL.Query().Get("last_event_id")
+	}
+	seq, resumed := h.parseID(last)
+	if !resumed {
+		seq = h.head()
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // keep proxies from buffering
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", liveRetryMin.Milliseconds())
+	if last != "" && !resumed {
+		if writeEvent(w, h.eventID(seq), "reset", map[string]int64{"seq": seq}) != nil {
+			return
+		}
+	}
+	fl.Flush()
+
+	ping := time.NewTicker(eventPing)
+	defer ping.Stop()
+	for {
+		evs, head, complete := h.since(seq)
+		if !complete {
+			if writeEvent(w, h.eventID(head), "reset", map[string]int64{"seq": head}) != nil {
+				return
+			}
+			seq = head
+		}
+		for _, ev := range evs {
+			if writeEvent(w, h.eventID(ev.Seq), ev.Type, ev) != nil {
+				return
+			}
+			seq = ev.Seq
+		}
+		fl.Flush()
+
+		select {
+		case <-wake:
+		case <-ping.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			fl.Flush()
+		case <-r.Context().Done():
+			return
+		case <-h.closed:
+			return
+		}
+	}
+}
+
+// watch picks up edits made outside the API (CLI, TUI) so subscribers see
+// them without waiting for the next request.
+func (s *server) watch(ctx context.Context) {
+	last, _ := storeStamp()
+	t := time.NewTicker(storePollInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		st, err := storeStamp()
+		if err != nil || st == last {
+			continue
+		}
+		s.mu.Lock()
+		if _, err := s.load(); err == nil {
+			last, _ = storeStamp() // load may have written new revisions
+		}
+		s.mu.Unlock()
+	}
+}
+
+// ---------------------------------------------------
+// client side
+// ---------------------------------------------------
+
+// stream reads events from GET /events until ctx ends or the connection
+// drops, calling onOpen once connected and fn for every event. It returns
+// the ID of the last event seen, to resume from.
+func (c *syncClient) stream(ctx context.Context, lastID string, onOpen func(), fn func(typ string, data []byte)) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.base+"/events", nil)
+	if err != nil {
+		return lastID, err
+	}
+	token := c.bearer()
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "text/event-stream")
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	// no overall timeout: the response never ends on its own
+	resp, err := (&http.Client{Transport: c.http.Transport}).Do(req)
+	if err != nil {
+		return lastID, fmt.Errorf("%w: %v", errOffline, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized && c.reauth(token) {
+		resp.Body.Close()
+		return c.stream(ctx, lastID, onOpen, fn)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var b [512]byte
+		n, _ := resp.Body.Read(b[:])
+		return lastID, &apiError{resp.StatusCode, serverMessage(b[:n])}
+	}
+	onOpen()
+
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 64*1024), maxBodyBytes)
+	var typ, id string
+	var data []byte
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" { // end of event
+			if data != nil {
+				if id != "" {
+					lastID = id
+				}
+				if typ == "" {
+					typ = "message"
+				}
+				fn(typ, data)
+			}
+			typ, id, data = "", "", nil
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			typ = value
+		case "id":
+			id = value
+		case "data":
+			if data != nil {
+				data = append(data, '\n')
+			}
+			data = append(data, value...)
+		}
+	}
+	if err := sc.Err(); err != nil && ctx.Err() == nil {
+		return lastID, fmt.Errorf("%w: %v", errOffline, err)
+	}
+	return lastID, ctx.Err()
+}
+
+// ---------------------------------------------------
+// TUI subscription (`todo ls --live`)
+// ---------------------------------------------------
+
+// liveSync keeps the TUI's list in step with a tada server: every event
+// triggers a pull, every local save a push (see sync.go).
+type liveSync struct {
+	client *syncClient
+	list   string // the list being synced; other tabs stay local
+	msgs   chan tea.Msg
+	cancel context.CancelFunc
+
+	state string // shown in the status bar
+	due   bool   // a sync was requested while another write ran
+}
+
+// liveEventMsg reports a change (or a reset) from the server.
+type liveEventMsg struct{ typ string }
+
+// liveStateMsg reports the connection state; err is why it dropped.
+type liveStateMsg struct {
+	connected bool
+	err       error
+}
+
+// liveSyncedMsg reports a finished pull+push; items is the list afterwards.
+type liveSyncedMsg struct {
+	items []Item
+	stamp fileStamp
+	err   error
+}
+
+func newLiveSync() (*liveSync, error) {
+	base, err := syncURL("")
+	if err != nil {
+		return nil, err
+	}
+	ti, _ := GetToken()
+	if ti == nil || strings.TrimSpace(ti.Token) == "" {
+		return nil, errors.New("no token found. Set TADA_TOKEN or run `todo auth login`")
+	}
+	if nt, err := refreshIfDue(ti, time.Now()); err == nil {
+		ti = nt
+	}
+	if _, err := ti.checkExpiry(time.Now()); err != nil {
+		return nil, fmt.Errorf("%w. Run `todo auth login` for a new one", err)
+	}
+	client := newSyncClient(base, ti.Token)
+	client.refresh = tokenRefresher(ti)
+	return &liveSync{
+		client: client,
+		list:   activeList,
+		msgs:   make(chan tea.Msg, 16),
+		state:  "connecting…",
+	}, nil
+}
+
+// start subscribes in the background, reconnecting with backoff.
+func (l *liveSync) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancel = cancel
+	send := func(msg tea.Msg) {
+		select {
+		case l.msgs <- msg:
+		case <-ctx.Done():
+		}
+	}
+	go func() {
+		lastID := ""
+		wait := liveRetryMin
+		for ctx.Err() == nil {
+			var err error
+			lastID, err = l.client.stream(ctx, lastID,
+				func() { wait = liveRetryMin; send(liveStateMsg{connected: true}) },
+				func(typ string, _ []byte) { send(liveEventMsg{typ}) })
+			if ctx.Err() != nil {
+				return
+			}
+			send(liveStateMsg{err: err})
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return
+			}
+			wait = min(wait*2, liveRetryMax)
+		}
+	}()
+}
+
+func (l *liveSync) stop() {
+	if l.cancel != nil {
+		l.cancel()
+	}
+}
+
+// next waits for the subscription's next message.
+func (l *liveSync) next() tea.Cmd {
+	return func() tea.Msg { return <-l.msgs }
+}
+
+// run pulls then pushes the synced list. A push refused for conflicts is
+// retried once after pulling the server's revisions. Like a save it holds
+// the gate, so the final save on quit waits for it and none starts after.
+func (l *liveSync) run(g *saveGate) tea.Cmd {
+	c, name := l.client, l.list
+	return func() tea.Msg {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if g.closed {
+			return liveSyncedMsg{err: errors.New("quitting")}
+		}
+		_, err := syncPull(c, name, false)
+		for try := 0; err == nil && try < 2; try++ {
+			var conflicts []string
+			if _, conflicts, _, err = syncPush(c, name); err != nil || len(conflicts) == 0 {
+				break
+			}
+			_, err = syncPull(c, name, false)
+		}
+		items, lerr := loadList(name)
+		if lerr != nil {
+			return liveSyncedMsg{err: lerr}
+		}
+		st, serr := storeStamp()
+		return liveSyncedMsg{items: items, stamp: st, err: errors.Join(err, serr)}
+	}
+}
+
+// requestLiveSync syncs now, or once the running save or sync finishes.
+func (m *modelTUI) requestLiveSync() tea.Cmd {
+	if m.live == nil {
+		return nil
+	}
+	m.live.due = true
+	if m.saving {
+		return nil
+	}
+	m.live.due = false
+	m.saving = true // saves wait for the sync, like for each other
+	return m.live.run(m.gate)
+}
+
+func (m *modelTUI) updateLive(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case liveStateMsg:
+		if !msg.connected {
+			m.live.state = "offline"
+			m.refreshStatus()
+			return m.live.next()
+		}
+		m.live.state = "live"
+		m.refreshStatus()
+		// catch up on what happened while disconnected
+		return tea.Batch(m.live.next(), m.requestLiveSync())
+	case liveEventMsg:
+		return tea.Batch(m.live.next(), m.requestLiveSync())
+	case liveSyncedMsg:
+		m.saving = false
+		var cmd tea.Cmd
+		switch {
+		case msg.err != nil && errors.Is(msg.err, errOffline):
+			m.live.state = "offline"
+		case msg.err != nil:
+			m.live.state = "sync error: " + msg.err.Error()
+		default:
+			m.live.state = "live"
+			if activeList == m.live.list {
+				m.stamp = msg.stamp
+				cmd = m.applyExternal(msg.items)
+			}
+		}
+		m.refreshStatus()
+		switch {
+		case m.changed:
+			return tea.Batch(cmd, m.scheduleSave())
+		case m.live.due:
+			return tea.Batch(cmd, m.requestLiveSync())
+		}
+		return cmd
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+package internal
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Exporters write the active list in other tools' formats. Where a format
+// can hold a field, it is written the way the matching importer in
+// import.go reads it back.
+
+const exportUsage = "usage: todo export --format todotxt|markdown|csv|html|ics [-o file]"
+
+var exportFormats = map[string]func(io.Writer, []Item, time.Time) error{
+	"todotxt":  writeTodoTxt,
+	"markdown": writeMarkdown,
+	"csv":      writeCSV,
+	"html":     writeHTML,
+	"ics":      writeICS,
+}
+
+// ---------------------------------------------------
+// todo.txt
+// ---------------------------------------------------
+
+var priorityLetter = map[Priority]string{PriorityHigh: "A", PriorityMedium: "B", PriorityLow: "C"}
+
+// writeTodoTxt follows the todo.txt rules: done items start with "x" and
+// their completion date, and keep their priority as pri: since "(A)" is
+// only allowed on pending items.
+func writeTodoTxt(w io.Writer, items []Item, _ time.Time) error {
+	for _, it := range items {
+		var f []string
+		if it.Done {
+			f = append(f, "x")
+			if it.CompletedAt != nil {
+				f = append(f, it.CompletedAt.Local().Format(dateLayout))
+				if it.CreatedAt != nil {
+					f = append(f, it.CreatedAt.Local().Format(dateLayout))
+				}
+			}
+		} else {
+			if l, has := priorityLetter[it.Priority]; has {
+				f = append(f, "("+l+")")
+			}
+			if it.CreatedAt != nil {
+				f = append(f, it.CreatedAt.Local().Format(dateLayout))
+			}
+		}
+		f = append(f, strings.Fields(it.Title)...)
+		if it.Project != "" {
+			f = append(f, "+"+strings.ReplaceAll(it.Project, " ", "_"))
+		}
+		for _, t := range it.Tags {
+			f = append(f, "@"+t)
+		}
+		if it.Due != nil {
+			f = append(f, "due:"+it.Due.Format(dateLayout))
+		}
+		if it.Scheduled != nil {
+			f = append(f, "t:"+it.Scheduled.Format(dateLayout))
+		}
+		if l, has := priorityLetter[it.Priority]; has && it.Done {
+			f = append(f, "pri:"+l)
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(f, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ---------------------------------------------------
+// Markdown
+// ---------------------------------------------------
+
+// writeMarkdown writes a task list under a heading naming the list. Titles
+// are written as-is so the file imports back unchanged.
+func writeMarkdown(w io.Writer, items []Item, _ time.Time) error {
+	if _, err := fmt.Fprintf(w, "## %s\n\n", activeList); err != nil {
+		return err
+	}
+	for _, it := range items {
+		box := " "
+		if it.Done {
+			box = "x"
+		}
+		if _, err := fmt.Fprintf(w, "- [%s] %s\n", box, it.Title); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ---------------------------------------------------
+// CSV
+// ---------------------------------------------------
+
+func writeCSV(w io.Writer, items []Item, _ time.Time) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvColumns); err != nil {
+		return err
+	}
+	day := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(dateLayout)
+	}
+	stamp := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	for _, it := range items {
+		rec := []string{
+			it.Title,
+			fmt.Sprint(it.Done),
+			string(it.Status),
+			string(it.Priority),
+			it.Project,
+			strings.Join(it.Tags, " "),
+			day(it.Due),
+			day(it.Scheduled),
+			stamp(it.CreatedAt),
+			stamp(it.CompletedAt),
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ---------------------------------------------------
+// HTML
+// ---------------------------------------------------
+
+var htmlExport = template.Must(template.New("export").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.List}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 40rem; margin: 2rem auto; }
+ul { list-style: none; padding: 0; }
+li { padding: .25rem 0; }
+.done { color: #888; text-decoration: line-through; }
+.meta { color: #888; font-size: .85em; margin-left: .5em; }
+</style>
+</head>
+<body>
+<h1>{{.List}}</h1>
+<p class="meta">{{.Done}} of {{len .Items}} done · exported {{.Now}}</p>
+<ul>
+{{- range .Items}}
+<li{{if .Done}} class="done"{{end}}><input type="checkbox" disabled{{if .Done}} checked{{end}}> {{.Title}}
+{{- if .Priority}}<span class="meta">!{{.Priority}}</span>{{end}}
+{{- if .Project}}<span class="meta">+{{.Project}}</span>{{end}}
+{{- range .Tags}}<span class="meta">#{{.}}</span>{{end}}
+{{- if .Due}}<span class="meta">due {{.Due.Format "2006-01-02"}}</span>{{end}}</li>
+{{- end}}
+</ul>
+</body>
+</html>
+`))
+
+func writeHTML(w io.Writer, items []Item, now time.Time) error {
+	done, _ := stats(items)
+	return htmlExport.Execute(w, struct {
+		List  string
+		Items []Item
+		Done  int
+		Now   string
+	}{activeList, items, done, now.Local().Format("2006-01-02 15:04")})
+}
+
+// ---------------------------------------------------
+// iCalendar (RFC 5545 VTODO)
+// ---------------------------------------------------
+
+// icsPriority uses the RFC 5545 bands: 1-4 high, 5 medium, 6-9 low.
+var icsPriority = map[Priority]int{PriorityHigh: 1, PriorityMedium: 5, PriorityLow: 9}
+
+var icsStatus = map[Status]string{StatusTodo: "NEEDS-ACTION", StatusDoing: "IN-PROCESS", StatusDone: "COMPLETED"}
+
+// icsText escapes a TEXT value (RFC 5545 §3.3.11).
+func icsText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// icsLine folds a content line at 75 octets (RFC 5545 §3.1) without
+// splitting a UTF-8 sequence, and terminates it with CRLF.
+func icsLine(b *strings.Builder, line string) {
+	for first := true; ; first = false {
+		limit := 75
+		if !first {
+			limit = 74 // the leading space counts
+			b.WriteString(" ")
+		}
+		if len(line) <= limit {
+			b.WriteString(line + "\r\n")
+			return
+		}
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n")
+		line = line[cut:]
+	}
+}
+
+func writeICS(w io.Writer, items []Item, now time.Time) error {
+	const (
+		stampLayout = "20060102T150405Z"
+		dayLayout   = "20060102"
+	)
+	var b strings.Builder
+	icsLine(&b, "BEGIN:VCALENDAR")
+	icsLine(&b, "VERSION:2.0")
+	icsLine(&b, "PRODID:-//Makepad//tada//EN")
+	icsLine(&b, "X-WR-CALNAME:"+icsText(activeList))
+	for _, it := range items {
+		icsLine(&b, "BEGIN:VTODO")
+		icsLine(&b, "UID:"+it.ID+"@tada")
+		icsLine(&b, "DTSTAMP:"+now.UTC().Format(stampLayout))
+		icsLine(&b, "SUMMARY:"+icsText(it.Title))
+		st := it.Status
+		if st == "" {
+			st = StatusTodo
+		}
+		icsLine(&b, "STATUS:"+icsStatus[st])
+		if p, has := icsPriority[it.Priority]; has {
+			icsLine(&b, fmt.Sprintf("PRIORITY:%d", p))
+		}
+		if it.Scheduled != nil {
+			icsLine(&b, "DTSTART;VALUE=DATE:"+it.Scheduled.Format(dayLayout))
+		}
+		if it.Due != nil {
+			icsLine(&b, "DUE;VALUE=DATE:"+it.Due.Format(dayLayout))
+		}
+		if it.CreatedAt != nil {
+			icsLine(&b, "CREATED:"+it.CreatedAt.UTC().Format(stampLayout))
+		}
+		if it.Done {
+			icsLine(&b, "PERCENT-COMPLETE:100")
+			if it.CompletedAt != nil {
+				icsLine(&b, "COMPLETED:"+it.CompletedAt.UTC().Format(stampLayout))
+			}
+		}
+		if len(it.Tags) > 0 {
+			cats := make([]string, len(it.Tags))
+			for i, t := range it.Tags {
+				cats[i] = icsText(t)
+			}
+			sort.Strings(cats)
+			icsLine(&b, "CATEGORIES:"+strings.Join(cats, ","))
+		}
+		icsLine(&b, "END:VTODO")
+	}
+	icsLine(&b, "END:VCALENDAR")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ---------------------------------------------------
+// export command
+// ---------------------------------------------------
+
+func doExport(format, out string) int {
+	write, known := exportFormats[format]
+	if !known {
+		fail("export: unknown format " + format)
+		fmt.Fprintln(os.Stderr, mutedStyle.Render("Hint: "+exportUsage))
+		return 2
+	}
+	items, err := Load()
+	if err != nil {
+		fail("load: " + err.Error())
+		return 1
+	}
+	if out == "" || out == "-" {
+		if err := write(os.Stdout, items, time.Now()); err != nil {
+			fail("export: " + err.Error())
+			return 1
+		}
+		return 0
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		fail("export: " + err.Error())
+		return 1
+	}
+	if err := write(f, items, time.Now()); err != nil {
+		f.Close()
+		fail("export: " + err.Error())
+		return 1
+	}
+	if err := f.Close(); err != nil {
+		fail("export: " + err.Error())
+		return 1
+	}
+	ok(fmt.Sprintf("exported %d items to %s", len(items), out))
+	return 0
+}
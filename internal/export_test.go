@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// exportFixture covers every field some exporter writes: dates, priority,
+// tags, project, estimate, tracked time, and text needing escapes.
+func exportFixture() []Item {
+	at := func(s string) *time.Time {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			panic(err)
+		}
+		return &t
+	}
+	return []Item{
+		{
+			ID: "a1", Title: "Write report", Status: StatusTodo,
+			CreatedAt: at("2026-03-01T09:00:00Z"), Due: at("2026-03-10T00:00:00Z"),
+			Scheduled: at("2026-03-08T00:00:00Z"),
+			Priority:  PriorityHigh, Tags: []string{"work", "q1"}, Project: "Annual review",
+			EstimateMinutes: 90,
+			Time:            []TimeEntry{{Start: *at("2026-03-02T10:00:00Z"), End: at("2026-03-02T10:45:00Z")}},
+		},
+		{
+			ID: "b2", Title: `Buy milk, eggs; "bread" <fresh> & jam`, Done: true, Status: StatusDone,
+			CreatedAt: at("2026-03-02T08:00:00Z"), CompletedAt: at("2026-03-03T18:30:00Z"),
+			Priority: PriorityLow, Tags: []string{"home"},
+		},
+		{
+			ID: "c3", Title: "Plan trip", Status: StatusDoing,
+			CreatedAt: at("2026-03-04T12:00:00Z"), Priority: PriorityMedium,
+		},
+		{ID: "d4", Title: "Legacy item without dates", Status: StatusTodo},
+	}
+}
+
+func TestExportGolden(t *testing.T) {
+	prev := time.Local
+	time.Local = time.UTC
+	t.Cleanup(func() { time.Local = prev })
+	now := time.Date(2026, 3, 5, 12, 0, 0, 0, time.UTC)
+
+	ext := map[string]string{"todotxt": "txt", "markdown": "md", "csv": "csv", "html": "html", "ics": "ics"}
+	for format, write := range exportFormats {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := write(&buf, exportFixture(), now); err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", "export."+ext[format]+".golden")
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run go test ./internal -run Export -update to create it)", err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("%s export differs from %s:\n--- got\n%s\n--- want\n%s", format, golden, buf.Bytes(), want)
+			}
+		})
+	}
+}
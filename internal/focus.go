@@ -0,0 +1,382 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// FocusSession is one completed focus (Pomodoro) interval spent on an item.
+type FocusSession struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+const (
+	defaultFocusLen = 25 * time.Minute
+	defaultBreakLen = 5 * time.Minute
+)
+
+// FocusConfig is the "focus" section of the config file. Durations use
+// Go syntax ("25m", "1h"); empty means the default.
+type FocusConfig struct {
+	Length string `json:"length"`
+	Break  string `json:"break"`
+}
+
+func (c FocusConfig) durations() (focusLen, breakLen time.Duration, err error) {
+	focusLen, breakLen = defaultFocusLen, defaultBreakLen
+	if c.Length != "" {
+		if focusLen, err = time.ParseDuration(c.Length); err != nil || focusLen <= 0 {
+			return 0, 0, fmt.Errorf("config: focus.length: bad duration %q", c.Length)
+		}
+	}
+	if c.Break != "" {
+		if breakLen, err = time.ParseDuration(c.Break); err != nil || breakLen <= 0 {
+			return 0, 0, fmt.Errorf("config: focus.break: bad duration %q", c.Break)
+		}
+	}
+	return focusLen, breakLen, nil
+}
+
+type focusPhase int
+
+const (
+	phaseIdle focusPhase = iota // between intervals, waiting for f/b
+	phaseFocus
+	phaseBreak
+)
+
+// focusModel is a countdown timer for one item. It is used on its own by
+// `todo focus` and embedded in modelTUI for the `f` key.
+type focusModel struct {
+	itemID   string
+	title    string
+	focusLen time.Duration
+	breakLen time.Duration
+	keys     keyMap // toggle pauses, focus and board start a focus or a break
+
+	phase   focusPhase
+	started time.Time     // start of the current run (reset on resume)
+	elapsed time.Duration // time accumulated before the last pause
+	begun   time.Time     // start of the current interval, for the session record
+	paused  bool
+	gen     int // tick generation; ticks from an older run are ignored
+	done    int // focus sessions completed in this run
+}
+
+// focusTickMsg advances the countdown; gen identifies the run it belongs to.
+type focusTickMsg struct{ gen int }
+
+// focusDoneMsg is emitted when a focus interval completes, so the owner can
+// record the session on the item.
+type focusDoneMsg struct {
+	itemID  string
+	session FocusSession
+}
+
+func newFocusModel(it Item, focusLen, breakLen time.Duration, keys keyMap) focusModel {
+	return focusModel{itemID: it.ID, title: it.Title, focusLen: focusLen, breakLen: breakLen, keys: keys}
+}
+
+func (f *focusModel) start(phase focusPhase) tea.Cmd {
+	f.phase = phase
+	f.elapsed = 0
+	f.paused = false
+	f.started = time.Now()
+	f.begun = f.started
+	f.gen++
+	return f.tick()
+}
+
+func (f focusModel) tick() tea.Cmd {
+	gen := f.gen
+	return tea.Tick(time.Second, func(time.Time) tea.Msg { return focusTickMsg{gen: gen} })
+}
+
+func (f focusModel) length() time.Duration {
+	if f.phase == phaseBreak {
+		return f.breakLen
+	}
+	return f.focusLen
+}
+
+func (f focusModel) spent() time.Duration {
+	if f.paused || f.phase == phaseIdle {
+		return f.elapsed
+	}
+	return f.elapsed + time.Since(f.started)
+}
+
+func (f focusModel) Update(msg tea.Msg) (focusModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case focusTickMsg:
+		if msg.gen != f.gen || f.paused || f.phase == phaseIdle {
+			return f, nil
+		}
+		if f.spent() < f.length() {
+			return f, f.tick()
+		}
+		finished := f.phase
+		f.phase = phaseIdle
+		f.elapsed = 0
+		if finished == phaseFocus {
+			f.done++
+			sess := FocusSession{Start: f.begun, End: time.Now()}
+			id := f.itemID
+			return f, tea.Batch(
+				notifyTerminal("Focus done: "+f.title),
+				func() tea.Msg { return focusDoneMsg{itemID: id, session: sess} },
+			)
+		}
+		return f, notifyTerminal("Break over")
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, f.keys.Toggle):
+			if f.phase == phaseIdle {
+				return f, nil
+			}
+			if f.paused {
+				f.paused = false
+				f.started = time.Now()
+				f.gen++
+				return f, f.tick()
+			}
+			f.elapsed = f.spent()
+			f.paused = true
+		case key.Matches(msg, f.keys.Focus):
+			if f.phase == phaseIdle {
+				return f, f.start(phaseFocus)
+			}
+		case key.Matches(msg, f.keys.Board):
+			if f.phase == phaseIdle {
+				return f, f.start(phaseBreak)
+			}
+		}
+	}
+	return f, nil
+}
+
+func (f focusModel) View(width int) string {
+	label := "Focus"
+	st := accentStyle
+	switch {
+	case f.phase == phaseBreak:
+		label, st = "Break", successStyle
+	case f.phase == phaseIdle:
+		label, st = "Ready", mutedStyle
+	}
+	if f.paused {
+		label += " (paused)"
+	}
+
+	total, spent := f.length(), min(f.spent(), f.length())
+	if f.phase == phaseIdle {
+		spent = 0 // show the next focus interval in full
+	}
+	left := total - spent
+	barW := min(width-16, 40)
+
+	lines := []string{
+		titleStyle.Render(f.title),
+		"",
+		st.Render(label) + "  " + fmt.Sprintf("%02d:%02d", int(left.Minutes()), int(left.Seconds())%60),
+		progressBar(int(spent.Seconds()), int(total.Seconds()), barW),
+		"",
+		mutedStyle.Render(fmt.Sprintf("sessions this run: %d", f.done)),
+	}
+	help := []string{helpEntry("pause", f.keys.Toggle), "esc stop"}
+	if f.phase == phaseIdle {
+		help = []string{helpEntry("focus", f.keys.Focus), helpEntry("break", f.keys.Board), "esc stop"}
+	}
+	lines = append(lines, helpStyle.Render(strings.Join(help, " • ")))
+	return strings.Join(lines, "\n")
+}
+
+// notifyTerminal rings the bell and sends an OSC 9 desktop notification
+// (understood by iTerm2, WezTerm, Windows Terminal and others). It writes
+// to stderr in one call so it cannot split a frame the renderer is drawing
+// on stdout.
+func notifyTerminal(msg string) tea.Cmd {
+	return func() tea.Msg {
+		fmt.Fprint(os.Stderr, "\a\x1b]9;"+msg+"\x07")
+		return nil
+	}
+}
+
+// recordFocus appends a finished session to the stored item with id.
+func recordFocus(id string, sess FocusSession) error {
+	items, err := Load()
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		if items[i].ID == id {
+			items[i].Focus = append(items[i].Focus, sess)
+			return Save(items)
+		}
+	}
+	return fmt.Errorf("item %s no longer exists", id)
+}
+
+func focusTotal(sessions []FocusSession) time.Duration {
+	var d time.Duration
+	for _, s := range sessions {
+		d += s.End.Sub(s.Start)
+	}
+	return d
+}
+
+// ---------------------------------------------------
+// TUI focus mode
+// ---------------------------------------------------
+
+// startFocus opens the timer on the item under the cursor.
+func (m *modelTUI) startFocus() tea.Cmd {
+	li, ok := m.list.SelectedItem().(listItem)
+	if !ok {
+		return nil
+	}
+	f := newFocusModel(li.item(), m.focusLen, m.focusBreak, m.keys)
+	cmd := f.start(phaseFocus)
+	m.focus = &f
+	return cmd
+}
+
+// updateFocus handles keys while the timer is shown. Leaving drops the
+// running interval; sessions already completed stay recorded.
+func (m modelTUI) updateFocus(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if km, isKey := msg.(tea.KeyMsg); isKey {
+		switch {
+		case km.String() == "esc":
+			m.focus = nil
+			return m, nil
+		case key.Matches(km, m.keys.Quit):
+			return m, tea.Quit
+		}
+	}
+	f, cmd := m.focus.Update(msg)
+	m.focus = &f
+	return m, cmd
+}
+
+// addFocusSession records a finished interval on the list item; autosave
+// writes it like any other edit.
+func (m *modelTUI) addFocusSession(msg focusDoneMsg) tea.Cmd {
+	for i, it := range m.list.Items() {
+		li, ok := it.(listItem)
+		if !ok || li.Base.ID != msg.itemID {
+			continue
+		}
+		li.Base.Focus = append(append([]FocusSession(nil), li.Base.Focus...), msg.session)
+		m.touch()
+		return m.list.SetItem(i, li)
+	}
+	return nil
+}
+
+// ---------------------------------------------------
+// `todo focus` (standalone timer)
+// ---------------------------------------------------
+
+type focusProgram struct {
+	focus focusModel
+	err   error
+}
+
+func (p focusProgram) Init() tea.Cmd { return p.focus.tick() }
+
+func (p focusProgram) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if key.Matches(msg, p.focus.keys.Quit) || msg.String() == "esc" || msg.String() == "ctrl+c" {
+			return p, tea.Quit
+		}
+	case focusDoneMsg:
+		if err := recordFocus(msg.itemID, msg.session); err != nil {
+			p.err = err
+			return p, tea.Quit
+		}
+	}
+	var cmd tea.Cmd
+	p.focus, cmd = p.focus.Update(msg)
+	return p, cmd
+}
+
+func (p focusProgram) View() string {
+	return panelString(p.focus.View(defaultWidth))
+}
+
+// parseFocusArgs reads `<index> [duration] [--break duration]`; durations
+// not given keep the values passed in.
+func parseFocusArgs(a []string, focusLen, breakLen time.Duration) (index int, _, _ time.Duration, err error) {
+	if len(a) == 0 {
+		return 0, 0, 0, fmt.Errorf("usage: todo focus <index> [25m] [--break 5m]")
+	}
+	if index, err = strconv.Atoi(a[0]); err != nil {
+		return 0, 0, 0, fmt.Errorf("focus: not a number: %s", a[0])
+	}
+	rest := a[1:]
+	for len(rest) > 0 {
+		switch {
+		case rest[0] == "--break" && len(rest) > 1:
+			if breakLen, err = time.ParseDuration(rest[1]); err != nil || breakLen <= 0 {
+				return 0, 0, 0, fmt.Errorf("focus: bad break duration %q", rest[1])
+			}
+			rest = rest[2:]
+		default:
+			if focusLen, err = time.ParseDuration(rest[0]); err != nil || focusLen <= 0 {
+				return 0, 0, 0, fmt.Errorf("focus: bad duration %q (e.g. 25m)", rest[0])
+			}
+			rest = rest[1:]
+		}
+	}
+	return index, focusLen, breakLen, nil
+}
+
+func doFocus(userIndex int, focusLen, breakLen time.Duration, keys keyMap) int {
+	items, err := Load()
+	if err != nil {
+		fail("load: " + err.Error())
+		return 1
+	}
+	if userIndex < 1 || userIndex > len(items) {
+		fail(fmt.Sprintf("index out of range: have %d, got %d", len(items), userIndex))
+		fmt.Fprintln(os.Stderr, mutedStyle.Render("Hint: run `todo ls` to see valid indexes"))
+		return 2
+	}
+	it := items[userIndex-1]
+	if err := Save(items); err != nil { // persist the item's ID before timing it
+		fail("save: " + err.Error())
+		return 1
+	}
+
+	fp := focusProgram{focus: newFocusModel(it, focusLen, breakLen, keys)}
+	fp.focus.start(phaseFocus) // Init schedules the first tick
+	final, err := tea.NewProgram(fp, tea.WithAltScreen()).Run()
+	if err != nil {
+		fail("focus: " + err.Error())
+		return 1
+	}
+	if fm, isFocus := final.(focusProgram); isFocus {
+		if fm.err != nil {
+			fail("focus: " + fm.err.Error())
+			return 1
+		}
+		ok(fmt.Sprintf("%d session(s) recorded", fm.focus.done))
+	}
+	if items, err := Load(); err == nil {
+		for _, x := range items {
+			if x.ID == it.ID {
+				fmt.Printf("%s: %d sessions, %s focused in total\n", x.Title, len(x.Focus), focusTotal(x.Focus).Round(time.Minute))
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The focus screen follows rebound keys and lists them in its help.
+func TestFocusUsesKeymap(t *testing.T) {
+	keys, err := newKeyMap(KeymapConfig{Bindings: map[string][]string{
+		"toggle": {"x"}, "focus": {"F"}, "board": {"B"}, "quit": {"Q"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := newFocusModel(newItem("write"), defaultFocusLen, defaultBreakLen, keys)
+	typed := func(k string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)} }
+
+	if f, _ = f.Update(typed("f")); f.phase != phaseIdle {
+		t.Fatal("old focus key still starts a focus interval")
+	}
+	if help := f.View(80); !strings.Contains(help, "F focus") || !strings.Contains(help, "B break") {
+		t.Fatalf("help not built from the keymap:\n%s", help)
+	}
+	if f, _ = f.Update(typed("B")); f.phase != phaseBreak {
+		t.Fatal("rebound break key did not start a break")
+	}
+	if f, _ = f.Update(typed(" ")); f.paused {
+		t.Fatal("space still pauses")
+	}
+	if f, _ = f.Update(typed("x")); !f.paused {
+		t.Fatal("rebound toggle key did not pause")
+	}
+
+	p := focusProgram{focus: f}
+	if _, cmd := p.Update(typed("q")); cmd != nil {
+		if _, quit := cmd().(tea.QuitMsg); quit {
+			t.Fatal("old quit key still quits")
+		}
+	}
+	if _, cmd := p.Update(typed("Q")); cmd == nil {
+		t.Fatal("rebound quit key did not quit")
+	} else if _, quit := cmd().(tea.QuitMsg); !quit {
+		t.Fatal("rebound quit key did not quit")
+	}
+}
@@ -0,0 +1,231 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// todos.json is often committed next to code. Line-based merges of it end
+// in broken JSON, so `todo git install` registers an item-aware merge
+// driver (crdt.go) for it, and `todo sync --git` uses a repository as the
+// sync backend.
+
+const (
+	gitDriverName = "tada"
+	gitAttrsLine  = dataFileName + " merge=" + gitDriverName
+)
+
+// gitFiles are the files `todo sync --git` commits, when they exist.
+var gitFiles = []string{dataFileName, listsFileName}
+
+// git runs git in dir and returns its trimmed output.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	msg := strings.TrimSpace(string(out))
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", errors.New("git is not installed")
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		return msg, fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return msg, nil
+}
+
+// dataDir is the directory holding the data file.
+func dataDir() (string, error) {
+	p, err := dataPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(p), nil
+}
+
+// driverCommand is how git should call us: by name when that finds this
+// binary on PATH, else by absolute path.
+func driverCommand() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return "todo"
+	}
+	if p, err := exec.LookPath(filepath.Base(exe)); err == nil {
+		a, errA := os.Stat(p)
+		b, errB := os.Stat(exe)
+		if errA == nil && errB == nil && os.SameFile(a, b) {
+			return filepath.Base(exe)
+		}
+	}
+	return exe
+}
+
+// installMergeDriver adds the .gitattributes line and the driver config to
+// the repository containing dir. It reports what it changed.
+func installMergeDriver(dir string) ([]string, error) {
+	root, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, errors.New("not inside a git repository")
+	}
+	var changed []string
+
+	attrs := filepath.Join(root, ".gitattributes")
+	b, err := os.ReadFile(attrs)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimRight(string(b), "\n"), "\n")
+	if !slices.Contains(lines, gitAttrsLine) {
+		text := string(b)
+		if text != "" && !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		if err := os.WriteFile(attrs, []byte(text+gitAttrsLine+"\n"), 0o644); err != nil {
+			return nil, err
+		}
+		changed = append(changed, ".gitattributes")
+	}
+
+	cmd := driverCommand() + " git-merge-driver %O %A %B"
+	key := "merge." + gitDriverName + ".driver"
+	if cur, _ := git(root, "config", "--local", "--get", key); cur != cmd {
+		if _, err := git(root, "config", "--local", "merge."+gitDriverName+".name", "tada item-aware merge"); err != nil {
+			return nil, err
+		}
+		if _, err := git(root, "config", "--local", key, cmd); err != nil {
+			return nil, err
+		}
+		changed = append(changed, ".git/config")
+	}
+	return changed, nil
+}
+
+// ---------------------------------------------------
+// git subcommands
+// ---------------------------------------------------
+
+const gitUsage = "usage: todo git install"
+
+func doGitInstall() int {
+	dir, err := dataDir()
+	if err != nil {
+		fail("git: " + err.Error())
+		return 1
+	}
+	changed, err := installMergeDriver(dir)
+	if err != nil {
+		fail("git install: " + err.Error())
+		return 1
+	}
+	if len(changed) == 0 {
+		ok("merge driver already installed")
+		return 0
+	}
+	ok("installed the " + dataFileName + " merge driver (" + strings.Join(changed, ", ") + ")")
+	fmt.Println(mutedStyle.Render("Commit .gitattributes so the rule travels with the repository; each clone runs `todo git install` once."))
+	return 0
+}
+
+// doGitMergeDriver is run by git as `todo git-merge-driver %O %A %B`: it
+// merges ours (%A) and theirs (%B) with the ancestor %O and writes the
+// result over %A. A non-zero exit leaves the file conflicted.
+func doGitMergeDriver(base, ours, theirs string) int {
+	lo, _, err := readItems(base)
+	if err != nil {
+		fail("merge driver: ancestor: " + err.Error())
+		return 1
+	}
+	positionLists(lo)
+	la, da, err := readReplica(ours, lo)
+	if err != nil {
+		fail("merge driver: ours: " + err.Error())
+		return 1
+	}
+	lb, db, err := readReplica(theirs, lo)
+	if err != nil {
+		fail("merge driver: theirs: " + err.Error())
+		return 1
+	}
+	live, dead := mergeReplicas(lo, la, lb, da, db)
+	data, err := encodeItems(live, dead)
+	if err != nil {
+		fail("merge driver: " + err.Error())
+		return 1
+	}
+	if err := writeFileAtomic(ours, data, 0o644); err != nil {
+		fail("merge driver: " + err.Error())
+		return 1
+	}
+	return 0
+}
+
+// ---------------------------------------------------
+// sync --git
+// ---------------------------------------------------
+
+// doSyncGit commits the list files in the data directory's repository,
+// then pulls (merging with the driver) and pushes if there is an upstream.
+func doSyncGit() int {
+	dir, err := dataDir()
+	if err != nil {
+		fail("sync: " + err.Error())
+		return 1
+	}
+	if _, err := installMergeDriver(dir); err != nil {
+		fail("sync --git: " + err.Error())
+		fmt.Fprintln(os.Stderr, mutedStyle.Render("Hint: run `git init` in "+dir+" and add a remote to sync through it"))
+		return 2
+	}
+
+	var files []string
+	for _, f := range gitFiles {
+		if _, err := os.Stat(filepath.Join(dir, f)); err == nil {
+			files = append(files, f)
+		}
+	}
+	if len(files) > 0 {
+		if _, err := git(dir, append([]string{"add", "--"}, files...)...); err != nil {
+			fail("sync --git: " + err.Error())
+			return 1
+		}
+	}
+	committed := false
+	if _, err := git(dir, append([]string{"diff", "--cached", "--quiet", "--"}, files...)...); len(files) > 0 && err != nil {
+		host, _ := os.Hostname()
+		msg := fmt.Sprintf("todo: sync %s from %s", time.Now().Format("2006-01-02 15:04"), host)
+		if _, err := git(dir, append([]string{"commit", "-m", msg, "--"}, files...)...); err != nil {
+			fail("sync --git: " + err.Error())
+			return 1
+		}
+		committed = true
+	}
+
+	if _, err := git(dir, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}"); err != nil {
+		if committed {
+			ok("committed the list (no upstream branch to pull from or push to)")
+		} else {
+			ok("nothing to commit (no upstream branch to pull from or push to)")
+		}
+		fmt.Fprintln(os.Stderr, mutedStyle.Render("Hint: git push -u <remote> <branch> once to set it up"))
+		return 0
+	}
+	if _, err := git(dir, "pull", "--no-rebase", "--no-edit"); err != nil {
+		fail("sync --git: " + err.Error())
+		fmt.Fprintln(os.Stderr, mutedStyle.Render("Hint: resolve with git in "+dir+", then run `todo sync --git` again"))
+		return 1
+	}
+	if _, err := git(dir, "push"); err != nil {
+		fail("sync --git: " + err.Error())
+		return 1
+	}
+	ok("list synced through git")
+	return 0
+}
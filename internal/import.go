@@ -0,0 +1,413 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Importers turn other tools' files into Items. Each parser only fills the
+// fields its format carries; IDs are assigned when the import is saved.
+
+const importUsage = "usage: todo import --from todotxt|taskwarrior|markdown|csv <file|-> [--dry-run]"
+
+// importFormats maps --from names to parsers.
+var importFormats = map[string]func(io.Reader) ([]Item, error){
+	"todotxt":     parseTodoTxt,
+	"taskwarrior": parseTaskwarrior,
+	"markdown":    parseMarkdown,
+	"csv":         parseCSV,
+}
+
+// csvColumns is the CSV layout written by `todo export` and read back by
+// `todo import --from csv` (only "title" is required on import).
+var csvColumns = []string{"title", "done", "status", "priority", "project", "tags", "due", "scheduled", "created", "completed"}
+
+func parseDay(s string) (*time.Time, error) {
+	t, err := time.ParseInLocation(dateLayout, s, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("bad date %q (want YYYY-MM-DD)", s)
+	}
+	return &t, nil
+}
+
+// ---------------------------------------------------
+// todo.txt
+// ---------------------------------------------------
+
+var todoTxtDate = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// todoTxtPriority maps (A) to high, (B) to medium and anything lower to low.
+func todoTxtPriority(letter byte) Priority {
+	switch letter {
+	case 'A':
+		return PriorityHigh
+	case 'B':
+		return PriorityMedium
+	}
+	return PriorityLow
+}
+
+// parseTodoTxt reads the todo.txt format: "x" marks done items, "(A)" is
+// the priority, dates before the text are completion/creation dates,
+// +project and @context words become Project and Tags, and the due: and
+// t: (threshold) keys become Due and Scheduled.
+func parseTodoTxt(r io.Reader) ([]Item, error) {
+	var items []Item
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		f := strings.Fields(sc.Text())
+		if len(f) == 0 {
+			continue
+		}
+		var it Item
+		if f[0] == "x" {
+			it.Done = true
+			f = f[1:]
+		}
+		if len(f) > 0 && len(f[0]) == 3 && f[0][0] == '(' && f[0][2] == ')' && f[0][1] >= 'A' && f[0][1] <= 'Z' {
+			it.Priority = todoTxtPriority(f[0][1])
+			f = f[1:]
+		}
+		// done items may carry "completion creation", others just "creation"
+		var dates []*time.Time
+		for len(f) > 0 && len(dates) < 2 && todoTxtDate.MatchString(f[0]) {
+			d, err := parseDay(f[0])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			dates = append(dates, d)
+			f = f[1:]
+		}
+		switch {
+		case it.Done && len(dates) == 2:
+			it.CompletedAt, it.CreatedAt = dates[0], dates[1]
+		case it.Done && len(dates) == 1:
+			it.CompletedAt = dates[0]
+		case len(dates) > 0:
+			it.CreatedAt = dates[0]
+		}
+
+		var words []string
+		for _, w := range f {
+			switch {
+			case len(w) > 1 && w[0] == '+':
+				if it.Project == "" {
+					it.Project = w[1:]
+				} else {
+					it.Tags = append(it.Tags, strings.ToLower(w[1:]))
+				}
+			case len(w) > 1 && w[0] == '@':
+				it.Tags = append(it.Tags, strings.ToLower(w[1:]))
+			case strings.HasPrefix(w, "due:") && todoTxtDate.MatchString(w[4:]):
+				d, _ := parseDay(w[4:])
+				it.Due = d
+			case strings.HasPrefix(w, "t:") && todoTxtDate.MatchString(w[2:]):
+				d, _ := parseDay(w[2:])
+				it.Scheduled = d
+			case strings.HasPrefix(w, "pri:") && len(w) == 5 && w[4] >= 'A' && w[4] <= 'Z':
+				// some clients move the priority of done items into pri:
+				it.Priority = todoTxtPriority(w[4])
+			default:
+				words = append(words, w)
+			}
+		}
+		if len(words) == 0 {
+			return nil, fmt.Errorf("line %d: no task text", n)
+		}
+		it.Title = strings.Join(words, " ")
+		items = append(items, it)
+	}
+	return items, sc.Err()
+}
+
+// ---------------------------------------------------
+// Taskwarrior (`task export`)
+// ---------------------------------------------------
+
+type twTask struct {
+	Description string   `json:"description"`
+	Status      string   `json:"status"` // pending, completed, deleted, waiting, recurring
+	Entry       string   `json:"entry"`
+	End         string   `json:"end"`
+	Due         string   `json:"due"`
+	Scheduled   string   `json:"scheduled"`
+	Project     string   `json:"project"`
+	Tags        []string `json:"tags"`
+	Priority    string   `json:"priority"` // H, M, L
+}
+
+const twTimeLayout = "20060102T150405Z"
+
+func twTime(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(twTimeLayout, s)
+	if err != nil {
+		return nil, fmt.Errorf("bad taskwarrior date %q", s)
+	}
+	t = t.Local()
+	return &t, nil
+}
+
+// twDay keeps only the local date, as Due and Scheduled are whole days.
+func twDay(s string) (*time.Time, error) {
+	t, err := twTime(s)
+	if t == nil || err != nil {
+		return t, err
+	}
+	d := dayStart(*t)
+	return &d, nil
+}
+
+// parseTaskwarrior reads the JSON array printed by `task export`. Deleted
+// tasks and recurrence templates are skipped.
+func parseTaskwarrior(r io.Reader) ([]Item, error) {
+	var tasks []twTask
+	if err := json.NewDecoder(r).Decode(&tasks); err != nil {
+		return nil, fmt.Errorf("taskwarrior json: %w", err)
+	}
+	var items []Item
+	for i, t := range tasks {
+		if t.Status == "deleted" || t.Status == "recurring" {
+			continue
+		}
+		if strings.TrimSpace(t.Description) == "" {
+			return nil, fmt.Errorf("task %d: no description", i+1)
+		}
+		it := Item{Title: t.Description, Done: t.Status == "completed", Project: t.Project}
+		for _, tag := range t.Tags {
+			it.Tags = append(it.Tags, strings.ToLower(tag))
+		}
+		switch t.Priority {
+		case "H":
+			it.Priority = PriorityHigh
+		case "M":
+			it.Priority = PriorityMedium
+		case "L":
+			it.Priority = PriorityLow
+		}
+		var err error
+		if it.CreatedAt, err = twTime(t.Entry); err != nil {
+			return nil, err
+		}
+		if it.Done {
+			if it.CompletedAt, err = twTime(t.End); err != nil {
+				return nil, err
+			}
+		}
+		if it.Due, err = twDay(t.Due); err != nil {
+			return nil, err
+		}
+		if it.Scheduled, err = twDay(t.Scheduled); err != nil {
+			return nil, err
+		}
+		items = append(items, it)
+	}
+	return items, nil
+}
+
+// ---------------------------------------------------
+// Markdown checklists
+// ---------------------------------------------------
+
+var mdTask = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+\[([ xX])\]\s+(.+?)\s*$`)
+
+// parseMarkdown reads GitHub-style task lists ("- [ ] todo", "- [x] done");
+// other lines are ignored.
+func parseMarkdown(r io.Reader) ([]Item, error) {
+	var items []Item
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		m := mdTask.FindStringSubmatch(sc.Text())
+		if m == nil {
+			continue
+		}
+		items = append(items, Item{Title: m[2], Done: m[1] != " "})
+	}
+	return items, sc.Err()
+}
+
+// ---------------------------------------------------
+// CSV
+// ---------------------------------------------------
+
+// parseCSV reads a CSV file with a header row naming csvColumns (any order,
+// case-insensitive; unknown columns are ignored).
+func parseCSV(r io.Reader) ([]Item, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("csv header: %w", err)
+	}
+	col := map[string]int{}
+	for i, h := range header {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	if _, has := col["title"]; !has {
+		return nil, fmt.Errorf("csv: no \"title\" column (have %s)", strings.Join(header, ", "))
+	}
+	var items []Item
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		get := func(name string) string {
+			if i, has := col[name]; has && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		it := Item{Title: get("title"), Project: get("project")}
+		if it.Title == "" {
+			continue
+		}
+		switch strings.ToLower(get("done")) {
+		case "true", "yes", "1", "x":
+			it.Done = true
+		}
+		if st, ok := parseStatus(get("status")); ok {
+			it.Status = st
+			it.Done = it.Done || st == StatusDone
+		}
+		if p := strings.ToLower(get("priority")); p != "" {
+			var known bool
+			if it.Priority, known = parsePriority(p); !known {
+				return nil, fmt.Errorf("line %d: bad priority %q", line, p)
+			}
+		}
+		if tags := get("tags"); tags != "" {
+			if it.Tags, err = applyTags(nil, strings.FieldsFunc(tags, func(r rune) bool { return r == ' ' || r == ';' || r == ',' })); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		for _, f := range []struct {
+			name string
+			dst  **time.Time
+		}{{"due", &it.Due}, {"scheduled", &it.Scheduled}, {"created", &it.CreatedAt}, {"completed", &it.CompletedAt}} {
+			v := get(f.name)
+			if v == "" {
+				continue
+			}
+			if t, err := time.Parse(time.RFC3339, v); err == nil {
+				*f.dst = &t
+				continue
+			}
+			d, err := parseDay(v)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s: %w", line, f.name, err)
+			}
+			*f.dst = d
+		}
+		items = append(items, it)
+	}
+	return items, nil
+}
+
+// ---------------------------------------------------
+// import command
+// ---------------------------------------------------
+
+// dedupeKey identifies an item for de-duplication: case and spacing of the
+// title do not matter.
+func dedupeKey(title string) string {
+	return strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
+
+func doImport(format, path string, dryRun bool) int {
+	parse, known := importFormats[format]
+	if !known {
+		fail("import: unknown format " + format)
+		fmt.Fprintln(os.Stderr, mutedStyle.Render("Hint: "+importUsage))
+		return 2
+	}
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			fail("import: " + err.Error())
+			return 1
+		}
+		defer f.Close()
+		in = f
+	}
+	parsed, err := parse(in)
+	if err != nil {
+		fail("import: " + err.Error())
+		return 1
+	}
+	items, err := Load()
+	if err != nil {
+		fail("load: " + err.Error())
+		return 1
+	}
+
+	seen := map[string]bool{}
+	for _, it := range items {
+		seen[dedupeKey(it.Title)] = true
+	}
+	var added []Item
+	skipped := 0
+	for _, it := range parsed {
+		k := dedupeKey(it.Title)
+		if seen[k] {
+			skipped++
+			if dryRun {
+				fmt.Println(mutedStyle.Render("  = " + it.Title + " (duplicate, skipped)"))
+			}
+			continue
+		}
+		seen[k] = true
+		it.ID = newID()
+		it.normalize()
+		added = append(added, it)
+		if dryRun {
+			fmt.Println(successStyle.Render("  + ") + importSummary(it))
+		}
+	}
+
+	if dryRun {
+		fmt.Println(mutedStyle.Render(fmt.Sprintf("dry run: would import %d, skip %d duplicate(s) into %s", len(added), skipped, activeList)))
+		return 0
+	}
+	if err := Save(append(items, added...)); err != nil {
+		fail("save: " + err.Error())
+		return 1
+	}
+	ok(fmt.Sprintf("imported %d, skipped %d duplicate(s)", len(added), skipped))
+	return 0
+}
+
+// importSummary is the dry-run preview line for an item.
+func importSummary(it Item) string {
+	box := boxUnchecked
+	if it.Done {
+		box = boxChecked
+	}
+	parts := []string{box + " " + it.Title}
+	if it.Priority != "" {
+		parts = append(parts, "!"+string(it.Priority))
+	}
+	if it.Project != "" {
+		parts = append(parts, "+"+it.Project)
+	}
+	for _, t := range it.Tags {
+		parts = append(parts, "#"+t)
+	}
+	if it.Due != nil {
+		parts = append(parts, "due "+it.Due.Format(dateLayout))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,195 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// describe renders the fields importers fill, one line per item, so a
+// table test can compare them at a glance.
+func describe(items []Item) string {
+	day := func(t *time.Time) string {
+		if t == nil {
+			return "-"
+		}
+		return t.Format(time.RFC3339)
+	}
+	var b strings.Builder
+	for _, it := range items {
+		it.normalize()
+		fmt.Fprintf(&b, "%s|%v|%s|%s|%s|%s|due %s|sched %s|created %s|completed %s\n",
+			it.Title, it.Done, it.Status, it.Priority, it.Project, strings.Join(it.Tags, ","),
+			day(it.Due), day(it.Scheduled), day(it.CreatedAt), day(it.CompletedAt))
+	}
+	return b.String()
+}
+
+func TestParseTodoTxt(t *testing.T) {
+	inUTC(t)
+	for _, tc := range []struct {
+		name, in, want string
+	}{
+		{"plain", "Call mom\n", "Call mom|false|todo||||due -|sched -|created -|completed -\n"},
+		{"priority and creation date",
+			"(A) 2026-03-01 Write report +Annual @Work @q1 due:2026-03-10 t:2026-03-08\n",
+			"Write report|false|todo|high|Annual|work,q1|due 2026-03-10T00:00:00Z|sched 2026-03-08T00:00:00Z|created 2026-03-01T00:00:00Z|completed -\n"},
+		{"done with both dates and pri:",
+			"x 2026-03-03 2026-03-02 Buy milk pri:C\n",
+			"Buy milk|true|done|low|||due -|sched -|created 2026-03-02T00:00:00Z|completed 2026-03-03T00:00:00Z\n"},
+		{"done with completion date only", "x 2026-03-03 Buy milk\n",
+			"Buy milk|true|done||||due -|sched -|created -|completed 2026-03-03T00:00:00Z\n"},
+		{"second project becomes a tag, lower priorities are low", "(D) Plan +trip +Summer\n",
+			"Plan|false|todo|low|trip|summer|due -|sched -|created -|completed -\n"},
+		{"blank lines and bad keys", "\n  \nfix due:soon t:x\n",
+			"fix due:soon t:x|false|todo||||due -|sched -|created -|completed -\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			items, err := parseTodoTxt(strings.NewReader(tc.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := describe(items); got != tc.want {
+				t.Errorf("got\n%swant\n%s", got, tc.want)
+			}
+		})
+	}
+	for _, in := range []string{"x 2026-03-03\n", "(A) +proj @ctx\n", "2026-13-45 bad date\n"} {
+		if _, err := parseTodoTxt(strings.NewReader(in)); err == nil {
+			t.Errorf("parseTodoTxt(%q) accepted", in)
+		}
+	}
+}
+
+func TestParseTaskwarrior(t *testing.T) {
+	inUTC(t)
+	in := `[
+		{"description": "Write report", "status": "pending", "entry": "20260301T090000Z",
+		 "due": "20260310T230000Z", "project": "work", "tags": ["Q1"], "priority": "H"},
+		{"description": "Buy milk", "status": "completed", "entry": "20260302T080000Z", "end": "20260303T183000Z", "priority": "L"},
+		{"description": "Old", "status": "deleted"},
+		{"description": "Every week", "status": "recurring"},
+		{"description": "Later", "status": "waiting", "scheduled": "20260308T120000Z", "priority": "M"}
+	]`
+	items, err := parseTaskwarrior(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Write report|false|todo|high|work|q1|due 2026-03-10T00:00:00Z|sched -|created 2026-03-01T09:00:00Z|completed -\n" +
+		"Buy milk|true|done|low|||due -|sched -|created 2026-03-02T08:00:00Z|completed 2026-03-03T18:30:00Z\n" +
+		"Later|false|todo|medium|||due -|sched 2026-03-08T00:00:00Z|created -|completed -\n"
+	if got := describe(items); got != want {
+		t.Errorf("got\n%swant\n%s", got, want)
+	}
+	for _, in := range []string{`{}`, `[{"description": " "}]`, `[{"description": "x", "due": "2026-03-10"}]`} {
+		if _, err := parseTaskwarrior(strings.NewReader(in)); err == nil {
+			t.Errorf("parseTaskwarrior(%s) accepted", in)
+		}
+	}
+}
+
+func TestParseMarkdown(t *testing.T) {
+	in := "# Groceries\n\n" +
+		"- [ ] Milk\n" +
+		"* [x] Eggs  \n" +
+		"  + [X] Bread\n" +
+		"1. [ ] Jam\n" +
+		"2) [ ] Butter\n" +
+		"- [] not a task\n" +
+		"- plain bullet\n" +
+		"-[ ] no space\n"
+	items, err := parseMarkdown(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, it := range items {
+		got = append(got, fmt.Sprintf("%s:%v", it.Title, it.Done))
+	}
+	if want := "Milk:false Eggs:true Bread:true Jam:false Butter:false"; strings.Join(got, " ") != want {
+		t.Errorf("got %v, want %s", got, want)
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	inUTC(t)
+	in := "Extra,TITLE,done,Status,priority,tags,due,created\n" +
+		"a,Write report,,doing,HIGH,\"work; Q1,#misc\",2026-03-10,2026-03-01T09:00:00Z\n" +
+		"b,Buy milk,yes,,,,,\n" +
+		"c,,true,,,,,\n" +
+		"d,Ship it,no,done\n"
+	items, err := parseCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Write report|false|doing|high||misc,q1,work|due 2026-03-10T00:00:00Z|sched -|created 2026-03-01T09:00:00Z|completed -\n" +
+		"Buy milk|true|done||||due -|sched -|created -|completed -\n" +
+		"Ship it|true|done||||due -|sched -|created -|completed -\n"
+	if got := describe(items); got != want {
+		t.Errorf("got\n%swant\n%s", got, want)
+	}
+	for _, in := range []string{
+		"",
+		"name,done\nx,true\n",
+		"title,priority\nx,urgent\n",
+		"title,due\nx,10/03/2026\n",
+		"title,tags\nx,a b\ty\n",
+	} {
+		if _, err := parseCSV(strings.NewReader(in)); err == nil {
+			t.Errorf("parseCSV(%q) accepted", in)
+		}
+	}
+}
+
+// Every exporter with a matching importer reads back what the format can
+// hold: keep reduces an item to those fields.
+func TestImportRoundTrip(t *testing.T) {
+	inUTC(t)
+	now := time.Date(2026, 3, 5, 12, 0, 0, 0, time.UTC)
+	day := func(t *time.Time) *time.Time {
+		if t == nil {
+			return nil
+		}
+		d := dayStart(*t)
+		return &d
+	}
+	for format, keep := range map[string]func(Item) Item{
+		"todotxt": func(it Item) Item {
+			return Item{
+				Title: it.Title, Done: it.Done, Priority: it.Priority,
+				Project: strings.ReplaceAll(it.Project, " ", "_"), Tags: it.Tags,
+				Due: it.Due, Scheduled: it.Scheduled,
+				CreatedAt: day(it.CreatedAt), CompletedAt: day(it.CompletedAt),
+			}
+		},
+		"markdown": func(it Item) Item { return Item{Title: it.Title, Done: it.Done} },
+		"csv": func(it Item) Item {
+			return Item{
+				Title: it.Title, Done: it.Done, Status: it.Status, Priority: it.Priority,
+				Project: it.Project, Tags: slices.Sorted(slices.Values(it.Tags)), Due: it.Due, Scheduled: it.Scheduled,
+				CreatedAt: it.CreatedAt, CompletedAt: it.CompletedAt,
+			}
+		},
+	} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := exportFormats[format](&buf, exportFixture(), now); err != nil {
+				t.Fatal(err)
+			}
+			items, err := importFormats[format](&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want []Item
+			for _, it := range exportFixture() {
+				want = append(want, keep(it))
+			}
+			if got, want := describe(items), describe(want); got != want {
+				t.Errorf("round trip through %s:\n--- got\n%s--- want\n%s", format, got, want)
+			}
+		})
+	}
+}
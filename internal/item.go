@@ -1,7 +1,136 @@
 package internal
 
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
 // Item is the domain model for a todo entry.
 type Item struct {
-	Title string `json:"title"`
-	Done  bool   `json:"done"`
+	ID     string `json:"id,omitempty"`  // stable identity across saves and processes
+	Rev    int    `json:"rev,omitempty"` // server revision, bumped only by `todo serve`
+	Title  string `json:"title"`
+	List   string `json:"list,omitempty"` // named list; empty is the default list
+	Done   bool   `json:"done"`
+	Status Status `json:"status,omitempty"` // refines Done for the board view
+
+	Due       *time.Time `json:"due,omitempty"`       // deadline (date only)
+	Scheduled *time.Time `json:"scheduled,omitempty"` // day planned to work on it
+
+	Focus []FocusSession `json:"focus,omitempty"` // completed focus (Pomodoro) sessions
+
+	Tags     []string `json:"tags,omitempty"`
+	Priority Priority `json:"priority,omitempty"`
+
+	CreatedAt   *time.Time `json:"created_at,omitempty"`   // unknown for items added by older builds
+	CompletedAt *time.Time `json:"completed_at,omitempty"` // set when marked done, cleared when reopened
+
+	Project         string      `json:"project,omitempty"`
+	EstimateMinutes int         `json:"estimate_minutes,omitempty"`
+	Time            []TimeEntry `json:"time,omitempty"` // tracked intervals (todo start/stop)
+
+	// Merge metadata, maintained by saveAll (see crdt.go)
+	Pos    string           `json:"pos,omitempty"`    // fractional index: manual order within the list
+	Stamps map[string]int64 `json:"stamps,omitempty"` // last change of each field, unix ms
+}
+
+// newItem returns a fresh pending item created now.
+func newItem(title string) Item {
+	now := time.Now()
+	return Item{ID: newID(), Title: title, Status: StatusTodo, CreatedAt: &now}
+}
+
+// completionTime is the CompletedAt value for an item whose Done flag just
+// changed to done.
+func completionTime(done bool) *time.Time {
+	if !done {
+		return nil
+	}
+	now := time.Now()
+	return &now
+}
+
+// newID returns a random item ID.
+func newID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("crypto/rand: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+// legacyID derives an ID for an item stored before IDs existed. It is
+// deterministic so every process reading the same file agrees on it until
+// the file is saved with real IDs.
+func legacyID(pos int, title string) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%d\x00%s", pos, title)))
+	return hex.EncodeToString(sum[:6])
+}
+
+// Status is the workflow state of an item (a board column).
+type Status string
+
+const (
+	StatusTodo  Status = "todo"
+	StatusDoing Status = "doing"
+	StatusDone  Status = "done"
+)
+
+// statuses lists the board columns in display order.
+var statuses = []Status{StatusTodo, StatusDoing, StatusDone}
+
+func parseStatus(s string) (Status, bool) {
+	for _, st := range statuses {
+		if string(st) == s {
+			return st, true
+		}
+	}
+	return "", false
+}
+
+// Priority ranks an item; empty means none.
+type Priority string
+
+const (
+	PriorityHigh   Priority = "high"
+	PriorityMedium Priority = "medium"
+	PriorityLow    Priority = "low"
+)
+
+// priorities lists the priorities from highest to lowest.
+var priorities = []Priority{PriorityHigh, PriorityMedium, PriorityLow}
+
+func parsePriority(s string) (Priority, bool) {
+	for _, p := range priorities {
+		if string(p) == s {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// normalize keeps Status consistent with Done. Done stays authoritative so
+// files edited by older builds (which only know Done) still read correctly.
+func (it *Item) normalize() {
+	switch {
+	case it.Done:
+		it.Status = StatusDone
+	case it.Status == StatusDone, it.Status == "":
+		it.Status = StatusTodo
+	}
+}
+
+// moveItem moves the item at from to position to (both 0-based) and returns
+// the reordered slice. to is the final position of the item after the move.
+func moveItem(items []Item, from, to int) []Item {
+	if from == to {
+		return items
+	}
+	it := items[from]
+	items = append(items[:from], items[from+1:]...)
+	items = append(items[:to], append([]Item{it}, items[to:]...)...)
+	return items
 }
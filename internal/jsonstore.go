@@ -1,11 +1,13 @@
 package internal
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 const dataFileName = "todos.json"
@@ -18,36 +20,197 @@ func dataPath() (string, error) {
 	return filepath.Join(wd, dataFileName), nil
 }
 
+// Load reads the items of the active list (see lists.go).
 func Load() ([]Item, error) {
+	return loadList(activeList)
+}
+
+// Save replaces the items of the active list, leaving other lists alone.
+func Save(items []Item) error {
+	return saveList(activeList, items)
+}
+
+// loadAll reads every item of every list, in file order.
+func loadAll() ([]Item, error) {
 	p, err := dataPath()
 	if err != nil {
 		return nil, err
 	}
+	items, _, err := readItems(p)
+	return items, err
+}
+
+// readItems parses a data file into its items and the tombstones of
+// deleted items (see crdt.go). A missing file is empty.
+func readItems(p string) ([]Item, []tombstone, error) {
 	b, err := os.ReadFile(p)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return []Item{}, nil
+			return []Item{}, nil, nil
 		}
-		return nil, fmt.Errorf("read file: %w", err)
+		return nil, nil, fmt.Errorf("read file: %w", err)
 	}
-	var items []Item
-	if err := json.Unmarshal(b, &items); err != nil {
-		return nil, fmt.Errorf("json unmarshal: %w", err)
+	if len(bytes.TrimSpace(b)) == 0 {
+		return []Item{}, nil, nil // e.g. git's empty ancestor in a merge
 	}
-	return items, nil
+	var recs []struct {
+		Item
+		Deleted *time.Time `json:"deleted,omitempty"`
+	}
+	if err := json.Unmarshal(b, &recs); err != nil {
+		return nil, nil, fmt.Errorf("json unmarshal: %w", err)
+	}
+	items := make([]Item, 0, len(recs))
+	var dead []tombstone
+	for i, r := range recs {
+		if r.Deleted != nil {
+			dead = append(dead, tombstone{ID: r.ID, List: r.List, Deleted: *r.Deleted, Stamps: r.Stamps})
+			continue
+		}
+		it := r.Item
+		it.normalize()
+		if it.ID == "" {
+			it.ID = legacyID(i, it.Title)
+		}
+		items = append(items, it)
+	}
+	return items, dead, nil
 }
 
-func Save(items []Item) error {
+// encodeItems formats a data file: items in order, then tombstones.
+func encodeItems(items []Item, dead []tombstone) ([]byte, error) {
+	recs := make([]any, 0, len(items)+len(dead))
+	for _, it := range items {
+		recs = append(recs, it)
+	}
+	for _, t := range dead {
+		recs = append(recs, t)
+	}
+	b, err := json.MarshalIndent(recs, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("json marshal: %w", err)
+	}
+	return b, nil
+}
+
+// saveAll writes every item of every list. Against the file's previous
+// content it records what changed for later merges: positions, field
+// stamps and tombstones for removed items.
+func saveAll(items []Item) error {
 	p, err := dataPath()
 	if err != nil {
 		return err
 	}
-	b, err := json.MarshalIndent(items, "", "  ")
+	prev, dead, err := readItems(p)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		if items[i].ID == "" {
+			items[i].ID = newID()
+		}
+	}
+	now := time.Now()
+	deadBy := make(map[string]tombstone, len(dead))
+	for _, t := range dead {
+		deadBy[t.ID] = t
+	}
+	positionLists(items)
+	stampChanges(itemsByID(prev), deadBy, items, now.UnixMilli())
+	b, err := encodeItems(items, buryRemoved(prev, dead, items, now))
 	if err != nil {
-		return fmt.Errorf("json marshal: %w", err)
+		return err
 	}
-	if err := os.WriteFile(p, b, 0o644); err != nil {
+	if err := writeFileAtomic(p, b, 0o644); err != nil {
 		return fmt.Errorf("write file: %w", err)
 	}
 	return nil
 }
+
+// writeFileAtomic replaces p with data so that readers, and a crash at any
+// point, see either the old or the new content: it writes a temporary file
+// next to p, syncs it and renames it over p.
+func writeFileAtomic(p string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(p), "."+filepath.Base(p)+".*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = f.Chmod(perm)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, p)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+func loadList(name string) ([]Item, error) {
+	all, err := loadAll()
+	if err != nil {
+		return nil, err
+	}
+	items := []Item{}
+	for _, it := range all {
+		if itemList(it) == name {
+			items = append(items, it)
+		}
+	}
+	return items, nil
+}
+
+// saveList writes items as the whole content of list name. Items of other
+// lists keep their order; the list's items follow them.
+func saveList(name string, items []Item) error {
+	all, err := loadAll()
+	if err != nil {
+		return err
+	}
+	out := make([]Item, 0, len(all)+len(items))
+	for _, it := range all {
+		if itemList(it) != name {
+			out = append(out, it)
+		}
+	}
+	for _, it := range items {
+		it.List = ""
+		if name != defaultListName {
+			it.List = name
+		}
+		out = append(out, it)
+	}
+	return saveAll(out)
+}
+
+// fileStamp identifies a version of the data file on disk.
+type fileStamp struct {
+	mod  time.Time
+	size int64
+}
+
+// storeStamp reports the data file's current stamp; a missing file has the
+// zero stamp.
+func storeStamp() (fileStamp, error) {
+	p, err := dataPath()
+	if err != nil {
+		return fileStamp{}, err
+	}
+	fi, err := os.Stat(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fileStamp{}, nil
+		}
+		return fileStamp{}, fmt.Errorf("stat: %w", err)
+	}
+	return fileStamp{mod: fi.ModTime(), size: fi.Size()}, nil
+}
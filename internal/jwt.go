@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Tokens that are JWTs carry their own expiry. The signature is not
+// checked (only the server can do that); the claims are read so the CLI
+// can tell an expired token apart from a rejected one before sending it.
+
+// tokenExpiryWarn is how long before expiry networked commands start
+// warning.
+const tokenExpiryWarn = time.Hour
+
+// jwtClaims are the registered claims tada looks at (RFC 7519 §4.1).
+type jwtClaims struct {
+	Exp *int64   `json:"exp"`
+	Iat *int64   `json:"iat"`
+	Sub string   `json:"sub"`
+	Aud audience `json:"aud"`
+}
+
+// audience is "aud", which is either a string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var one string
+	if json.Unmarshal(b, &one) == nil {
+		*a = audience{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return errors.New("aud: want a string or an array of strings")
+	}
+	*a = many
+	return nil
+}
+
+// parseJWT decodes the claims of a JWT without verifying it. Opaque tokens
+// return an error.
+func parseJWT(token string) (*jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("JWT payload: %w", err)
+	}
+	var c jwtClaims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return nil, fmt.Errorf("JWT claims: %w", err)
+	}
+	return &c, nil
+}
+
+func unixTime(s *int64) *time.Time {
+	if s == nil {
+		return nil
+	}
+	t := time.Unix(*s, 0).UTC()
+	return &t
+}
+
+// applyClaims fills in expiry, issue time, subject and audience from the
+// token when it is a JWT. An expiry already set (e.g. given at login) wins.
+func (ti *TokenInfo) applyClaims() {
+	c, err := parseJWT(ti.Token)
+	if err != nil {
+		return
+	}
+	if ti.ExpiresAt == nil {
+		ti.ExpiresAt = unixTime(c.Exp)
+	}
+	ti.IssuedAt = unixTime(c.Iat)
+	ti.Subject = c.Sub
+	ti.Audience = c.Aud
+}
+
+// remaining reports how long the token stays valid; known is false when
+// its expiry is unknown (opaque tokens).
+func (ti *TokenInfo) remaining(now time.Time) (d time.Duration, known bool) {
+	if ti.ExpiresAt == nil {
+		return 0, false
+	}
+	return ti.ExpiresAt.Sub(now), true
+}
+
+// fmtRemaining renders a duration coarsely: "2d 3h", "3h 12m", "4m", "30s".
+func fmtRemaining(d time.Duration) string {
+	d = d.Abs()
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd %dh", int(d.Hours())/24, int(d.Hours())%24)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%ds", int(d.Seconds()))
+}
+
+// checkExpiry returns an error for an expired token, and a warning for one
+// expiring within tokenExpiryWarn.
+func (ti *TokenInfo) checkExpiry(now time.Time) (warning string, err error) {
+	d, known := ti.remaining(now)
+	switch {
+	case !known:
+		return "", nil
+	case d <= 0:
+		return "", fmt.Errorf("token expired %s ago", fmtRemaining(d))
+	case d < tokenExpiryWarn:
+		return fmt.Sprintf("token expires in %s", fmtRemaining(d)), nil
+	}
+	return "", nil
+}
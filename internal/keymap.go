@@ -0,0 +1,286 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// keyMap holds the configurable bindings of the list, board and calendar
+// views. The same bindings drive Update and the help text, so the two cannot
+// drift apart.
+type keyMap struct {
+	CursorUp      key.Binding
+	CursorDown    key.Binding
+	CursorLeft    key.Binding
+	CursorRight   key.Binding
+	PrevPage      key.Binding
+	NextPage      key.Binding
+	GoToStart     key.Binding
+	GoToEnd       key.Binding
+	Quit          key.Binding
+	Toggle        key.Binding
+	Delete        key.Binding
+	Add           key.Binding
+	Edit          key.Binding
+	Tag           key.Binding
+	Priority      key.Binding
+	Undo          key.Binding
+	MoveUp        key.Binding
+	MoveDown      key.Binding
+	MoveLeft      key.Binding
+	MoveRight     key.Binding
+	Select        key.Binding
+	SelectVisible key.Binding
+	SelectRangeUp key.Binding
+	SelectRangeDn key.Binding
+	Board         key.Binding
+	Calendar      key.Binding
+	Today         key.Binding
+	Focus         key.Binding
+	NextList      key.Binding
+	PrevList      key.Binding
+}
+
+// keyAction describes one configurable action: its config name, help text
+// and where its binding lives in keyMap.
+type keyAction struct {
+	name string
+	help string
+	get  func(*keyMap) *key.Binding
+}
+
+var keyActions = []keyAction{
+	{"cursor_up", "up", func(k *keyMap) *key.Binding { return &k.CursorUp }},
+	{"cursor_down", "down", func(k *keyMap) *key.Binding { return &k.CursorDown }},
+	{"cursor_left", "prev page", func(k *keyMap) *key.Binding { return &k.CursorLeft }},
+	{"cursor_right", "next page", func(k *keyMap) *key.Binding { return &k.CursorRight }},
+	{"prev_page", "prev page", func(k *keyMap) *key.Binding { return &k.PrevPage }},
+	{"next_page", "next page", func(k *keyMap) *key.Binding { return &k.NextPage }},
+	{"go_to_start", "go to start", func(k *keyMap) *key.Binding { return &k.GoToStart }},
+	{"go_to_end", "go to end", func(k *keyMap) *key.Binding { return &k.GoToEnd }},
+	{"quit", "quit", func(k *keyMap) *key.Binding { return &k.Quit }},
+	{"toggle", "toggle", func(k *keyMap) *key.Binding { return &k.Toggle }},
+	{"delete", "delete", func(k *keyMap) *key.Binding { return &k.Delete }},
+	{"add", "add", func(k *keyMap) *key.Binding { return &k.Add }},
+	{"edit", "edit", func(k *keyMap) *key.Binding { return &k.Edit }},
+	{"tag", "tag", func(k *keyMap) *key.Binding { return &k.Tag }},
+	{"priority", "priority", func(k *keyMap) *key.Binding { return &k.Priority }},
+	{"undo", "undo", func(k *keyMap) *key.Binding { return &k.Undo }},
+	{"move_up", "move up", func(k *keyMap) *key.Binding { return &k.MoveUp }},
+	{"move_down", "move down", func(k *keyMap) *key.Binding { return &k.MoveDown }},
+	{"move_left", "move card left", func(k *keyMap) *key.Binding { return &k.MoveLeft }},
+	{"move_right", "move card right", func(k *keyMap) *key.Binding { return &k.MoveRight }},
+	{"select", "select", func(k *keyMap) *key.Binding { return &k.Select }},
+	{"select_visible", "select visible", func(k *keyMap) *key.Binding { return &k.SelectVisible }},
+	{"select_range_up", "select up", func(k *keyMap) *key.Binding { return &k.SelectRangeUp }},
+	{"select_range_down", "select down", func(k *keyMap) *key.Binding { return &k.SelectRangeDn }},
+	{"board", "board", func(k *keyMap) *key.Binding { return &k.Board }},
+	{"calendar", "calendar", func(k *keyMap) *key.Binding { return &k.Calendar }},
+	{"today", "today", func(k *keyMap) *key.Binding { return &k.Today }},
+	{"focus", "focus", func(k *keyMap) *key.Binding { return &k.Focus }},
+	{"next_list", "next list", func(k *keyMap) *key.Binding { return &k.NextList }},
+	{"prev_list", "prev list", func(k *keyMap) *key.Binding { return &k.PrevList }},
+}
+
+// keyPresets map action names to keys. Actions missing from a preset fall
+// back to "default".
+var keyPresets = map[string]map[string][]string{
+	"default": {
+		"cursor_up":         {"up", "k"},
+		"cursor_down":       {"down", "j"},
+		"cursor_left":       {"left", "h"},
+		"cursor_right":      {"right", "l"},
+		"prev_page":         {"pgup", "["},
+		"next_page":         {"pgdown", "]"},
+		"go_to_start":       {"home", "g"},
+		"go_to_end":         {"end", "G"},
+		"quit":              {"q"},
+		"toggle":            {" "},
+		"delete":            {"d"},
+		"add":               {"a"},
+		"edit":              {"e"},
+		"tag":               {"#"},
+		"priority":          {"!"},
+		"undo":              {"u"},
+		"move_up":           {"K", "alt+up"},
+		"move_down":         {"J", "alt+down"},
+		"move_left":         {"H", "shift+left"},
+		"move_right":        {"L", "shift+right"},
+		"select":            {"v"},
+		"select_visible":    {"V"},
+		"select_range_up":   {"shift+up"},
+		"select_range_down": {"shift+down"},
+		"board":             {"b"},
+		"calendar":          {"c"},
+		"today":             {"t"},
+		"focus":             {"f"},
+		"next_list":         {"tab"},
+		"prev_list":         {"shift+tab"},
+	},
+	"vim": {
+		"delete": {"x"},
+		"add":    {"o"},
+		"edit":   {"i"},
+	},
+	"emacs": {
+		"cursor_up":         {"up", "ctrl+p"},
+		"cursor_down":       {"down", "ctrl+n"},
+		"cursor_left":       {"left", "ctrl+b"},
+		"cursor_right":      {"right", "ctrl+f"},
+		"prev_page":         {"pgup", "alt+v"},
+		"next_page":         {"pgdown", "ctrl+v"},
+		"go_to_start":       {"home", "alt+<"},
+		"go_to_end":         {"end", "alt+>"},
+		"quit":              {"ctrl+x"},
+		"toggle":            {"ctrl+t"},
+		"delete":            {"ctrl+d"},
+		"add":               {"ctrl+o"},
+		"edit":              {"ctrl+e"},
+		"undo":              {"ctrl+_"},
+		"move_up":           {"alt+p", "alt+up"},
+		"move_down":         {"alt+n", "alt+down"},
+		"move_left":         {"shift+left"},
+		"move_right":        {"shift+right"},
+		"select":            {"ctrl+@"},
+		"select_visible":    {"alt+a"},
+		"select_range_up":   {"shift+up"},
+		"select_range_down": {"shift+down"},
+		"board":             {"alt+b"},
+		"calendar":          {"alt+c"},
+		"focus":             {"alt+f"},
+	},
+}
+
+// reservedKeys are handled outside keyMap and cannot be rebound.
+var reservedKeys = map[string]string{
+	"esc":    "clear selection/filter",
+	"enter":  "confirm",
+	"/":      "filter",
+	"?":      "help",
+	"ctrl+c": "force quit",
+}
+
+// normalizeKey maps config spellings onto Bubble Tea key names.
+func normalizeKey(k string) string {
+	if k == "space" {
+		return " "
+	}
+	return k
+}
+
+func keyLabel(k string) string {
+	if k == " " {
+		return "space"
+	}
+	return k
+}
+
+// newKeyMap builds the keymap from a preset plus per-action overrides and
+// rejects unknown actions and keys bound to more than one action. The
+// actions cover every binding of the bubbles list outside filtering (see
+// listKeyMap), so a key can no longer both page the list and run an action.
+func newKeyMap(cfg KeymapConfig) (keyMap, error) {
+	preset := cfg.Preset
+	if preset == "" {
+		preset = "default"
+	}
+	keys, ok := keyPresets[preset]
+	if !ok {
+		return keyMap{}, fmt.Errorf("keymap: unknown preset %q (want default, vim or emacs)", preset)
+	}
+
+	known := map[string]bool{}
+	for _, a := range keyActions {
+		known[a.name] = true
+	}
+	for name := range cfg.Bindings {
+		if !known[name] {
+			return keyMap{}, fmt.Errorf("keymap: unknown action %q", name)
+		}
+	}
+
+	var km keyMap
+	owner := map[string]string{}
+	var conflicts []string
+	for _, a := range keyActions {
+		ks, ok := cfg.Bindings[a.name]
+		if !ok {
+			if ks, ok = keys[a.name]; !ok {
+				ks = keyPresets["default"][a.name]
+			}
+		}
+		if len(ks) == 0 {
+			return keyMap{}, fmt.Errorf("keymap: action %q has no keys", a.name)
+		}
+		norm := make([]string, len(ks))
+		for i, k := range ks {
+			norm[i] = normalizeKey(k)
+			if what, ok := reservedKeys[norm[i]]; ok {
+				conflicts = append(conflicts, fmt.Sprintf("%q: %s and %s (reserved)", keyLabel(norm[i]), a.name, what))
+				continue
+			}
+			if other, ok := owner[norm[i]]; ok {
+				conflicts = append(conflicts, fmt.Sprintf("%q: %s and %s", keyLabel(norm[i]), other, a.name))
+				continue
+			}
+			owner[norm[i]] = a.name
+		}
+		*a.get(&km) = key.NewBinding(key.WithKeys(norm...), key.WithHelp(keyLabel(norm[0]), a.help))
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return keyMap{}, fmt.Errorf("keymap: conflicting keys: %s", strings.Join(conflicts, "; "))
+	}
+	return km, nil
+}
+
+func (k keyMap) shortHelp() []key.Binding {
+	return []key.Binding{k.Add, k.Edit, k.Undo, k.Select}
+}
+
+func (k keyMap) fullHelp() []key.Binding {
+	return []key.Binding{
+		k.Toggle, k.Delete, k.Add, k.Edit, k.Tag, k.Priority, k.Undo, k.MoveUp, k.MoveDown,
+		k.Select, k.SelectVisible, k.SelectRangeUp, k.SelectRangeDn, k.Board, k.Calendar, k.Focus,
+		k.NextList, k.PrevList,
+	}
+}
+
+// listKeyMap is the bubbles list's keymap with every binding outside the
+// filter input taken from k; the list's own defaults (b/u page up, f/d page
+// down) would otherwise shadow actions.
+func (k keyMap) listKeyMap() list.KeyMap {
+	lk := list.DefaultKeyMap()
+	lk.CursorUp = k.CursorUp
+	lk.CursorDown = k.CursorDown
+	lk.PrevPage = joinBindings(k.CursorLeft, k.PrevPage)
+	lk.NextPage = joinBindings(k.CursorRight, k.NextPage)
+	lk.GoToStart = k.GoToStart
+	lk.GoToEnd = k.GoToEnd
+	lk.Quit = k.Quit
+	return lk
+}
+
+// joinBindings merges bindings into one that answers all their keys and is
+// labelled like the first.
+func joinBindings(bs ...key.Binding) key.Binding {
+	var keys []string
+	for _, b := range bs {
+		keys = append(keys, b.Keys()...)
+	}
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(bs[0].Help().Key, bs[0].Help().Desc))
+}
+
+// helpEntry labels one line of the board or calendar help from the
+// bindings in use, e.g. "h/l column".
+func helpEntry(desc string, bs ...key.Binding) string {
+	labels := make([]string, len(bs))
+	for i, b := range bs {
+		labels[i] = b.Help().Key
+	}
+	return strings.Join(labels, "/") + " " + desc
+}
@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Keys the bubbles list pages with are part of the keymap, so rebinding an
+// action onto one of them is a conflict rather than a silent shadowing.
+func TestKeymapListConflicts(t *testing.T) {
+	for _, k := range []string{"pgdown", "home", "G", "h"} {
+		_, err := newKeyMap(KeymapConfig{Bindings: map[string][]string{"undo": {k}}})
+		if err == nil || !strings.Contains(err.Error(), "conflicting") {
+			t.Errorf("undo on %q: err = %v, want a conflict", k, err)
+		}
+	}
+	keys, err := newKeyMap(KeymapConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lk := keys.listKeyMap()
+	for _, k := range []string{"b", "u", "f", "d"} {
+		if slices.Contains(append(lk.PrevPage.Keys(), lk.NextPage.Keys()...), k) {
+			t.Errorf("list still pages on %q, which an action owns", k)
+		}
+	}
+}
+
+// The board and calendar follow rebound keys and list them in their help.
+func TestBoardAndCalendarUseKeymap(t *testing.T) {
+	m := testModel(t, newItem("a"))
+	var err error
+	m.keys, err = newKeyMap(KeymapConfig{Bindings: map[string][]string{
+		"board": {"B"}, "calendar": {"C"}, "quit": {"Q"}, "today": {"T"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.width, m.height = 120, 30
+
+	m = press(m, "B")
+	if !m.board {
+		t.Fatal("rebound board key did not open the board")
+	}
+	if help := m.boardView(116, 26); !strings.Contains(help, "B list") || !strings.Contains(help, "Q quit") {
+		t.Fatalf("board help not built from the keymap:\n%s", help)
+	}
+	if m = press(m, "b"); !m.board {
+		t.Fatal("old board key still closes the board")
+	}
+	m = press(m, "B", "C")
+	if !m.cal {
+		t.Fatal("rebound calendar key did not open the calendar")
+	}
+	m = press(m, "]", "T")
+	if !m.calDay.Equal(dayStart(time.Now())) {
+		t.Fatalf("today key: day = %v", m.calDay)
+	}
+	if help := m.calendarView(); !strings.Contains(help, "T today") || !strings.Contains(help, "C list") {
+		t.Fatalf("calendar help not built from the keymap:\n%s", help)
+	}
+	if m = press(m, "c"); !m.cal {
+		t.Fatal("old calendar key still closes the calendar")
+	}
+	if m = press(m, "C"); m.cal {
+		t.Fatal("rebound calendar key did not close the calendar")
+	}
+}
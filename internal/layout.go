@@ -0,0 +1,45 @@
+package internal
+
+// Sizes for the TUI come from tea.WindowSizeMsg, which Bubble Tea sends at
+// start-up and on every resize (tmux pane changes included). When stdout
+// is not a terminal no size arrives and the defaults below apply.
+const (
+	defaultWidth  = 80
+	defaultHeight = 24
+
+	panelChromeW = 4 // panel border (2) + horizontal padding (2)
+	panelChromeH = 2 // panel border
+	inputBarH    = 4 // input bar: border (2) + title + text input
+)
+
+// tuiLayout splits the terminal between the panel chrome, the list and the
+// optional input bar. Full-panel views (board, calendar) use innerW/innerH.
+type tuiLayout struct {
+	innerW, innerH int // space inside the panel
+	listW, listH   int // size handed to the bubbles list
+}
+
+func (m modelTUI) layout() tuiLayout {
+	w, h := m.width, m.height
+	if w <= 0 || h <= 0 {
+		w, h = defaultWidth, defaultHeight
+	}
+	lay := tuiLayout{
+		innerW: max(w-panelChromeW, 1),
+		innerH: max(h-panelChromeH, 1),
+	}
+	lay.listW, lay.listH = lay.innerW, lay.innerH
+	if m.adding || m.editing || m.bulk != "" || m.confirming {
+		lay.listH = max(lay.innerH-inputBarH, 1)
+	}
+	return lay
+}
+
+// relayout resizes the list when the terminal or the mode changed. It runs
+// from Update, so unlike a resize inside View the new size sticks.
+func (m *modelTUI) relayout() {
+	lay := m.layout()
+	if m.list.Width() != lay.listW || m.list.Height() != lay.listH {
+		m.list.SetSize(lay.listW, lay.listH)
+	}
+}
@@ -0,0 +1,416 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Named lists share the data file: every item carries its list name (empty
+// for the default list) and Load/Save only see the active one, so commands
+// work on a list without knowing about the others. lists.json next to the
+// data file remembers the tab order and lists that have no items yet.
+
+const (
+	defaultListName = "default"
+	listsFileName   = "lists.json"
+)
+
+// activeList is the list Load and Save work on; `-l name` changes it.
+var activeList = defaultListName
+
+var listNameRE = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+func itemList(it Item) string {
+	if it.List == "" {
+		return defaultListName
+	}
+	return it.List
+}
+
+func listsPath() (string, error) {
+	p, err := dataPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(p), listsFileName), nil
+}
+
+func loadListNames() ([]string, error) {
+	p, err := listsPath()
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read lists: %w", err)
+	}
+	var names []string
+	if err := json.Unmarshal(b, &names); err != nil {
+		return nil, fmt.Errorf("lists json unmarshal: %w", err)
+	}
+	return names, nil
+}
+
+func saveListNames(names []string) error {
+	p, err := listsPath()
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(names, "", "  ")
+	if err != nil {
+		return fmt.Errorf("lists json marshal: %w", err)
+	}
+	if err := writeFileAtomic(p, b, 0o644); err != nil {
+		return fmt.Errorf("write lists: %w", err)
+	}
+	return nil
+}
+
+// listNames returns every list: the default first, then registered lists in
+// creation order, then any list only known from its items.
+func listNames() ([]string, error) {
+	reg, err := loadListNames()
+	if err != nil {
+		return nil, err
+	}
+	all, err := loadAll()
+	if err != nil {
+		return nil, err
+	}
+	names := []string{defaultListName}
+	add := func(n string) {
+		if !slices.Contains(names, n) {
+			names = append(names, n)
+		}
+	}
+	for _, n := range reg {
+		add(n)
+	}
+	for _, it := range all {
+		add(itemList(it))
+	}
+	return names, nil
+}
+
+// UseList makes name the active list. It must exist.
+func UseList(name string) error {
+	names, err := listNames()
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(names, name) {
+		return fmt.Errorf("no list named %q (create it with `todo list create %s`)", name, name)
+	}
+	activeList = name
+	return nil
+}
+
+// ---------------------------------------------------
+// lists / list subcommands
+// ---------------------------------------------------
+
+const listUsage = "usage: todo list <create <name>|rename <old> <new>|delete <name> [--yes]>"
+
+func doLists() int {
+	names, err := listNames()
+	if err != nil {
+		fail("lists: " + err.Error())
+		return 1
+	}
+	all, err := loadAll()
+	if err != nil {
+		fail("load: " + err.Error())
+		return 1
+	}
+	counts := map[string][2]int{} // pending, done
+	for _, it := range all {
+		c := counts[itemList(it)]
+		if it.Done {
+			c[1]++
+		} else {
+			c[0]++
+		}
+		counts[itemList(it)] = c
+	}
+	for _, n := range names {
+		mark := "  "
+		if n == activeList {
+			mark = accentStyle.Render("* ")
+		}
+		c := counts[n]
+		fmt.Printf("%s%-16s %s\n", mark, n, mutedStyle.Render(fmt.Sprintf("%d pending, %d done", c[0], c[1])))
+	}
+	return 0
+}
+
+func doListCreate(name string) int {
+	if !listNameRE.MatchString(name) {
+		fail(fmt.Sprintf("list: bad name %q (letters, digits, '.', '_' and '-')", name))
+		return 2
+	}
+	names, err := listNames()
+	if err != nil {
+		fail("list: " + err.Error())
+		return 1
+	}
+	if slices.Contains(names, name) {
+		fail("list: " + name + " already exists")
+		return 2
+	}
+	reg, err := loadListNames()
+	if err != nil {
+		fail("list: " + err.Error())
+		return 1
+	}
+	if err := saveListNames(append(reg, name)); err != nil {
+		fail("list: " + err.Error())
+		return 1
+	}
+	ok("created list " + name)
+	return 0
+}
+
+func doListRename(from, to string) int {
+	if from == defaultListName || to == defaultListName {
+		fail("list: the default list cannot be renamed")
+		return 2
+	}
+	if !listNameRE.MatchString(to) {
+		fail(fmt.Sprintf("list: bad name %q (letters, digits, '.', '_' and '-')", to))
+		return 2
+	}
+	names, err := listNames()
+	if err != nil {
+		fail("list: " + err.Error())
+		return 1
+	}
+	if !slices.Contains(names, from) {
+		fail("list: no list named " + from)
+		fmt.Fprintln(os.Stderr, mutedStyle.Render("Hint: run `todo lists` to see them"))
+		return 2
+	}
+	if slices.Contains(names, to) {
+		fail("list: " + to + " already exists")
+		return 2
+	}
+	all, err := loadAll()
+	if err != nil {
+		fail("load: " + err.Error())
+		return 1
+	}
+	for i := range all {
+		if all[i].List == from {
+			all[i].List = to
+		}
+	}
+	if err := saveAll(all); err != nil {
+		fail("save: " + err.Error())
+		return 1
+	}
+	reg, err := loadListNames()
+	if err != nil {
+		fail("list: " + err.Error())
+		return 1
+	}
+	if i := slices.Index(reg, from); i >= 0 {
+		reg[i] = to
+	} else {
+		reg = append(reg, to)
+	}
+	if err := saveListNames(reg); err != nil {
+		fail("list: " + err.Error())
+		return 1
+	}
+	ok("renamed " + from + " to " + to)
+	return 0
+}
+
+// doListDelete removes a list; its items go to the trash.
+func doListDelete(name string, yes bool) int {
+	if name == defaultListName {
+		fail("list: the default list cannot be deleted")
+		return 2
+	}
+	names, err := listNames()
+	if err != nil {
+		fail("list: " + err.Error())
+		return 1
+	}
+	if !slices.Contains(names, name) {
+		fail("list: no list named " + name)
+		fmt.Fprintln(os.Stderr, mutedStyle.Render("Hint: run `todo lists` to see them"))
+		return 2
+	}
+	items, err := loadList(name)
+	if err != nil {
+		fail("load: " + err.Error())
+		return 1
+	}
+	if len(items) > 0 && !yes && !confirm(fmt.Sprintf("List %s has %d items. Move them to the trash and delete it?", name, len(items))) {
+		fail("list delete: cancelled (pass --yes to skip the prompt)")
+		return 2
+	}
+	prev := activeList
+	activeList = name
+	err = SaveTrashing(items, []Item{})
+	activeList = prev
+	if err != nil {
+		fail("save: " + err.Error())
+		return 1
+	}
+	reg, err := loadListNames()
+	if err != nil {
+		fail("list: " + err.Error())
+		return 1
+	}
+	if err := saveListNames(slices.DeleteFunc(reg, func(n string) bool { return n == name })); err != nil {
+		fail("list: " + err.Error())
+		return 1
+	}
+	ok(fmt.Sprintf("deleted list %s (%d items moved to trash)", name, len(items)))
+	return 0
+}
+
+// doMoveToList moves items of the active list to the end of list dst.
+func doMoveToList(idx []int, dst string) int {
+	names, err := listNames()
+	if err != nil {
+		fail("list: " + err.Error())
+		return 1
+	}
+	if !slices.Contains(names, dst) {
+		fail("mv: no list named " + dst)
+		fmt.Fprintln(os.Stderr, mutedStyle.Render("Hint: run `todo lists` to see them"))
+		return 2
+	}
+	if dst == activeList {
+		fail("mv: items are already in " + dst)
+		return 2
+	}
+	all, err := loadAll()
+	if err != nil {
+		fail("load: " + err.Error())
+		return 1
+	}
+	var pos []int // positions in all of the active list's items
+	for i, it := range all {
+		if itemList(it) == activeList {
+			pos = append(pos, i)
+		}
+	}
+	move := map[int]bool{}
+	for _, n := range idx {
+		if n < 1 || n > len(pos) {
+			fail(fmt.Sprintf("index out of range: have %d, got %d", len(pos), n))
+			fmt.Fprintln(os.Stderr, mutedStyle.Render("Hint: run `todo ls` to see valid indexes"))
+			return 2
+		}
+		move[pos[n-1]] = true
+	}
+	// one write: the moved items follow everything else, so they end up
+	// last in dst
+	out := make([]Item, 0, len(all))
+	var moved []Item
+	for i, it := range all {
+		if !move[i] {
+			out = append(out, it)
+			continue
+		}
+		it.List = ""
+		if dst != defaultListName {
+			it.List = dst
+		}
+		moved = append(moved, it)
+	}
+	if err := saveAll(append(out, moved...)); err != nil {
+		fail("save: " + err.Error())
+		return 1
+	}
+	ok(fmt.Sprintf("moved %d to %s", len(move), dst))
+	return 0
+}
+
+// ---------------------------------------------------
+// TUI list switcher
+// ---------------------------------------------------
+
+// switchList shows the next (delta 1) or previous (-1) list. Pending edits
+// are written first so they land in the list they were made in; while a
+// background save runs the switch is refused, since that save reads and
+// writes the active list when it executes.
+func (m *modelTUI) switchList(delta int) tea.Cmd {
+	if len(m.lists) < 2 {
+		return nil
+	}
+	if m.saving {
+		m.saveState = "saving…"
+		m.refreshStatus()
+		return nil
+	}
+	if m.changed {
+		disk, err := Load()
+		if err == nil {
+			err = SaveTrashing(disk, mergeItems(m.base, m.currentItems(), disk))
+		}
+		if err != nil {
+			m.saveState = "error: " + err.Error()
+			m.refreshStatus()
+			return nil
+		}
+		m.changed = false
+	}
+
+	i := slices.Index(m.lists, activeList)
+	prev := activeList
+	activeList = m.lists[((i+delta)%len(m.lists)+len(m.lists))%len(m.lists)]
+	items, err := Load()
+	if err != nil {
+		activeList = prev
+		m.saveState = "error: " + err.Error()
+		m.refreshStatus()
+		return nil
+	}
+	if st, err := storeStamp(); err == nil {
+		m.stamp = st
+	}
+	m.base = items
+	m.away = timersElsewhere(activeList)
+	rows := make([]list.Item, len(items))
+	for k, it := range items {
+		rows[k] = newListItem(k+1, it)
+	}
+	m.nextKey = len(items) + 1
+	clear(m.selected)
+	m.undo = nil
+	m.saveState = ""
+	m.list.ResetFilter()
+	cmd := m.list.SetItems(rows)
+	m.list.Select(0)
+	m.refreshStatus()
+	return cmd
+}
+
+// listTabs renders the list names for the top border of the TUI panel.
+func listTabs(names []string, active string) string {
+	tabs := make([]string, len(names))
+	for i, n := range names {
+		if n == active {
+			tabs[i] = selectedStyle.Render(" " + n + " ")
+		} else {
+			tabs[i] = mutedStyle.Render(" " + n + " ")
+		}
+	}
+	return strings.Join(tabs, " ")
+}
@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// inList runs fn with name as the active list, like `todo -l name`.
+func inList(name string, fn func()) {
+	prev := activeList
+	activeList = name
+	defer func() { activeList = prev }()
+	fn()
+}
+
+func TestMoveToListWritesOnce(t *testing.T) {
+	inTempDir(t)
+	if code := doListCreate("work"); code != 0 {
+		t.Fatalf("list create = %d", code)
+	}
+	if err := Save([]Item{newItem("a"), newItem("b"), newItem("c")}); err != nil {
+		t.Fatal(err)
+	}
+	inList("work", func() {
+		if err := Save([]Item{newItem("w")}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if code := doMoveToList([]int{1, 3}, "work"); code != 0 {
+		t.Fatalf("mv = %d", code)
+	}
+	all, err := loadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, it := range all {
+		got = append(got, itemList(it)+":"+it.Title)
+	}
+	want := []string{"default:b", "work:w", "work:a", "work:c"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("items %v, want %v", got, want)
+	}
+}
+
+// Only one timer runs across all lists: starting one in another list stops
+// it, and `todo stop` finds it wherever it runs.
+func TestTimerAcrossLists(t *testing.T) {
+	inTempDir(t)
+	if code := doListCreate("work"); code != 0 {
+		t.Fatalf("list create = %d", code)
+	}
+	if err := Save([]Item{newItem("home task")}); err != nil {
+		t.Fatal(err)
+	}
+	inList("work", func() {
+		if err := Save([]Item{newItem("work task")}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	running := func() int {
+		all, err := loadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for _, it := range all {
+			if k := len(it.Time); k > 0 && it.Time[k-1].End == nil {
+				n++
+			}
+		}
+		return n
+	}
+
+	if code := doStart(1); code != 0 {
+		t.Fatalf("start = %d", code)
+	}
+	inList("work", func() {
+		if code := doStart(1); code != 0 {
+			t.Fatalf("start in work = %d", code)
+		}
+	})
+	if n := running(); n != 1 {
+		t.Fatalf("%d timers running, want 1", n)
+	}
+	if tl := timerLabel(nil, timersElsewhere(defaultListName), time.Now()); !strings.Contains(tl, "work task") {
+		t.Fatalf("header misses the timer in another list: %q", tl)
+	}
+	if code := doStop(); code != 0 {
+		t.Fatalf("stop from the default list = %d", code)
+	}
+	if n := running(); n != 0 {
+		t.Fatalf("%d timers running after stop", n)
+	}
+}
@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// mergeItems is an item-level three-way merge keyed by Item.ID. base is the
+// common ancestor, local and remote the two edited versions. Local order is
+// kept; items added remotely are placed after their remote predecessor.
+//
+// Per item: a change on one side wins over an unchanged side; when both
+// sides changed, local wins. A deletion loses against an edit on the other
+// side, so no edit is silently dropped.
+func mergeItems(base, local, remote []Item) []Item {
+	baseBy := itemsByID(base)
+	localBy := itemsByID(local)
+	remoteBy := itemsByID(remote)
+
+	out := make([]Item, 0, len(local)+len(remote))
+	for _, l := range local {
+		b, inBase := baseBy[l.ID]
+		r, inRemote := remoteBy[l.ID]
+		switch {
+		case !inBase:
+			out = append(out, l) // added locally
+		case !inRemote:
+			if !sameItem(l, b) {
+				out = append(out, l) // edited locally, deleted remotely
+			}
+		case sameItem(l, b):
+			out = append(out, r)
+		default:
+			out = append(out, l)
+		}
+	}
+
+	for i, r := range remote {
+		if _, inLocal := localBy[r.ID]; inLocal {
+			continue
+		}
+		// added remotely, or edited remotely but deleted locally
+		if b, inBase := baseBy[r.ID]; inBase && sameItem(r, b) {
+			continue
+		}
+		pos := 0
+		if i > 0 {
+			pos = len(out)
+			for j := range out {
+				if out[j].ID == remote[i-1].ID {
+					pos = j + 1
+					break
+				}
+			}
+		}
+		out = append(out[:pos], append([]Item{r}, out[pos:]...)...)
+	}
+	return out
+}
+
+func itemsByID(items []Item) map[string]Item {
+	m := make(map[string]Item, len(items))
+	for _, it := range items {
+		m[it.ID] = it
+	}
+	return m
+}
+
+// sameItem compares items by their stored form, which ignores in-memory
+// details such as pointer identity or monotonic clock readings. The merge
+// metadata saveAll maintains (Pos, Stamps) is not content and is ignored:
+// callers compare against copies taken before a save stamped them.
+func sameItem(a, b Item) bool {
+	a.Pos, a.Stamps = "", nil
+	b.Pos, b.Stamps = "", nil
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return bytes.Equal(ja, jb)
+}
+
+func sameItems(a, b []Item) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !sameItem(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
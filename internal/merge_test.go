@@ -0,0 +1,28 @@
+package internal
+
+import "testing"
+
+// The TUI merges against the items it saved, which lack the stamps saveAll
+// wrote to disk. A delete after an edit must still win.
+func TestMergeIgnoresMergeMetadata(t *testing.T) {
+	inTempDir(t)
+	a := newItem("a")
+	if err := Save([]Item{a}); err != nil {
+		t.Fatal(err)
+	}
+	a.Title = "a2"
+	base := []Item{a}
+	if err := Save(base); err != nil {
+		t.Fatal(err)
+	}
+	disk, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(disk[0].Stamps) == 0 {
+		t.Fatal("saved item has no stamps")
+	}
+	if out := mergeItems(base, nil, disk); len(out) != 0 {
+		t.Fatalf("deleted item came back: %+v", out)
+	}
+}
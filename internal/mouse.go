@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Mouse support for the list view: click selects a row, clicking the
+// checkbox toggles it, the wheel scrolls and dragging a row reorders it.
+// Mouse events are only enabled with tea.WithMouseCellMotion when the
+// config allows it; every action stays reachable from the keyboard.
+
+const (
+	panelOriginX = 2 // panel border + left padding
+	panelOriginY = 1 // panel top border
+	rowPrefixW   = 2 // "> " drawn by itemDelegate.Render before the checkbox
+)
+
+// TUIConfig is the "tui" section of the config file.
+type TUIConfig struct {
+	Mouse *bool `json:"mouse"` // nil means enabled
+}
+
+func (c TUIConfig) mouseEnabled() bool {
+	return c.Mouse == nil || *c.Mouse
+}
+
+// rowAt maps a screen row to a visible list index, or -1 when y is not on
+// an item row. The offset mirrors list.Model.View: title bar, status bar,
+// then one line per item (itemDelegate.Height is 1, no spacing).
+func (m modelTUI) rowAt(y int) int {
+	top := panelOriginY
+	if m.list.ShowTitle() || (m.list.ShowFilter() && m.list.FilteringEnabled()) {
+		top += m.list.Styles.TitleBar.GetVerticalFrameSize() + 1
+	}
+	if m.list.ShowStatusBar() {
+		top += m.list.Styles.StatusBar.GetVerticalFrameSize() + 1
+	}
+	row := y - top
+	n := m.list.Paginator.ItemsOnPage(len(m.list.VisibleItems()))
+	if row < 0 || row >= n {
+		return -1
+	}
+	return m.list.Paginator.Page*m.list.Paginator.PerPage + row
+}
+
+// onCheckbox reports whether x falls on the checkbox glyph of a row.
+func onCheckbox(x int) bool {
+	return x == panelOriginX+rowPrefixW
+}
+
+func (m modelTUI) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if m.adding || m.editing || m.bulk != "" || m.confirming || m.board || m.cal || m.focus != nil || m.list.SettingFilter() {
+		return m, nil
+	}
+	switch {
+	case msg.Button == tea.MouseButtonWheelUp:
+		m.list.CursorUp()
+	case msg.Button == tea.MouseButtonWheelDown:
+		m.list.CursorDown()
+	case msg.Button == tea.MouseButtonLeft && msg.Action == tea.MouseActionPress:
+		row := m.rowAt(msg.Y)
+		if row < 0 {
+			return m, nil
+		}
+		m.list.Select(row)
+		li, ok := m.list.SelectedItem().(listItem)
+		if !ok {
+			return m, nil
+		}
+		if onCheckbox(msg.X) {
+			return m, m.toggleKey(li.Key)
+		}
+		m.dragKey, m.dragMoved = li.Key, false
+	case msg.Action == tea.MouseActionMotion && m.dragKey != 0:
+		if row := m.rowAt(msg.Y); row >= 0 {
+			return m, m.dragTo(row)
+		}
+	case msg.Action == tea.MouseActionRelease:
+		m.dragKey = 0
+	}
+	return m, nil
+}
+
+// toggleKey toggles a single item regardless of the current selection.
+func (m *modelTUI) toggleKey(k int) tea.Cmd {
+	for i, it := range m.list.Items() {
+		li, ok := it.(listItem)
+		if !ok || li.Key != k {
+			continue
+		}
+		m.snapshot()
+		if li.Done {
+			li.setStatus(StatusTodo)
+		} else {
+			li.setStatus(StatusDone)
+		}
+		m.touch()
+		return m.list.SetItem(i, li)
+	}
+	return nil
+}
+
+// dragTo moves the dragged item to visible position to. Like moveTargets it
+// permutes visible slots only, so it is safe while filtered. One snapshot is
+// taken per drag, so undo reverts the whole gesture.
+func (m *modelTUI) dragTo(to int) tea.Cmd {
+	pos := m.visibleIndices()
+	items := m.list.Items()
+	order := make([]list.Item, 0, len(pos))
+	from := -1
+	for k, p := range pos {
+		if li, ok := items[p].(listItem); ok && li.Key == m.dragKey {
+			from = k
+			continue
+		}
+		order = append(order, items[p])
+	}
+	if from < 0 || from == to || to >= len(pos) {
+		return nil
+	}
+	order = append(order[:to], append([]list.Item{items[pos[from]]}, order[to:]...)...)
+
+	if !m.dragMoved {
+		m.snapshot()
+		m.dragMoved = true
+	}
+	out := append([]list.Item(nil), items...)
+	for k, p := range pos {
+		out[p] = order[k]
+	}
+	m.touch()
+	cmd := m.list.SetItems(out)
+	m.list.Select(to)
+	return cmd
+}
@@ -0,0 +1,425 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// `todo auth login` gets a token with the OAuth 2.0 device authorization
+// grant (RFC 8628) when an authorization server is configured: the user
+// approves the login in a browser, possibly on another device, while the
+// CLI polls the token endpoint. The refresh token is kept in the profile
+// and used when the access token is about to expire or the server answers
+// 401.
+
+const (
+	deviceGrantType  = "urn:ietf:params:oauth:grant-type:device_code"
+	defaultClientID  = "tada-cli"
+	devicePollMin    = 5 * time.Second // RFC 8628 §3.2 default interval
+	deviceSlowDown   = 5 * time.Second // added on "slow_down" (§3.5)
+	deviceMaxTimeout = 30 * time.Minute
+
+	// tokenRefreshEarly is how long before expiry a refreshable token is
+	// renewed; access tokens often live only minutes.
+	tokenRefreshEarly = 2 * time.Minute
+)
+
+// AuthConfig is the "auth" section of the config file. Issuer alone is
+// enough when the server publishes its metadata (RFC 8414); the endpoints
+// can also be given directly.
+type AuthConfig struct {
+	Issuer    string `json:"issuer"`     // e.g. https://auth.example.com
+	ClientID  string `json:"client_id"`  // default "tada-cli"
+	Scope     string `json:"scope"`      // optional, space separated
+	DeviceURL string `json:"device_url"` // device authorization endpoint
+	TokenURL  string `json:"token_url"`  // token endpoint
+}
+
+// oauthGrant is what a profile needs to refresh its access token.
+type oauthGrant struct {
+	Issuer       string `json:"issuer,omitempty"`
+	TokenURL     string `json:"token_url"`
+	ClientID     string `json:"client_id"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// tokenResponse is a token endpoint answer, success or error (RFC 6749 §5).
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+
+	Error       string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+// UnmarshalJSON accepts expires_in as a number or a quoted number: RFC 6749
+// says number, but some servers quote it.
+func (tr *tokenResponse) UnmarshalJSON(b []byte) error {
+	type plain tokenResponse
+	var raw struct {
+		plain
+		ExpiresIn json.RawMessage `json:"expires_in"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*tr = tokenResponse(raw.plain)
+	if len(raw.ExpiresIn) > 0 {
+		s := strings.Trim(string(raw.ExpiresIn), `"`)
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			tr.ExpiresIn = n
+		}
+	}
+	return nil
+}
+
+// oauthError is an error answer from the authorization server.
+type oauthError struct {
+	code, desc string
+}
+
+func (e *oauthError) Error() string {
+	if e.desc != "" {
+		return e.code + ": " + e.desc
+	}
+	return e.code
+}
+
+var oauthHTTP = &http.Client{Timeout: syncTimeout}
+
+// devicePollWait waits between token polls; tests replace it to skip the
+// real intervals.
+var devicePollWait = time.After
+
+// postForm posts a form and decodes the JSON answer into out. Error
+// answers (4xx with an "error" member) become *oauthError.
+func postForm(endpoint string, form url.Values, out any) error {
+	resp, err := oauthHTTP.PostForm(endpoint, form)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errOffline, err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	if err != nil {
+		return fmt.Errorf("%w: %v", errOffline, err)
+	}
+	if resp.StatusCode >= 400 {
+		var e tokenResponse
+		if json.Unmarshal(b, &e) == nil && e.Error != "" {
+			return &oauthError{e.Error, e.Description}
+		}
+		return &apiError{resp.StatusCode, serverMessage(b)}
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("authorization server: %w", err)
+	}
+	return nil
+}
+
+// resolveEndpoints fills in the device and token endpoints from the
+// issuer's metadata when they are not configured.
+func resolveEndpoints(ac AuthConfig) (AuthConfig, error) {
+	if ac.ClientID == "" {
+		ac.ClientID = defaultClientID
+	}
+	if ac.DeviceURL != "" && ac.TokenURL != "" {
+		return ac, nil
+	}
+	if ac.Issuer == "" {
+		return ac, errors.New("no authorization server configured")
+	}
+	issuer := strings.TrimRight(ac.Issuer, "/")
+	var meta struct {
+		DeviceURL string `json:"device_authorization_endpoint"`
+		TokenURL  string `json:"token_endpoint"`
+	}
+	var lastErr error
+	for _, p := range []string{"/.well-known/oauth-authorization-server", "/.well-known/openid-configuration"} {
+		resp, err := oauthHTTP.Get(issuer + p)
+		if err != nil {
+			return ac, fmt.Errorf("%w: %v", errOffline, err)
+		}
+		b, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != http.StatusOK {
+			lastErr = fmt.Errorf("%s%s: %s", issuer, p, resp.Status)
+			continue
+		}
+		if err := json.Unmarshal(b, &meta); err != nil {
+			lastErr = fmt.Errorf("%s%s: %w", issuer, p, err)
+			continue
+		}
+		lastErr = nil
+		break
+	}
+	if lastErr != nil {
+		return ac, fmt.Errorf("authorization server metadata: %w", lastErr)
+	}
+	if ac.DeviceURL == "" {
+		ac.DeviceURL = meta.DeviceURL
+	}
+	if ac.TokenURL == "" {
+		ac.TokenURL = meta.TokenURL
+	}
+	if ac.DeviceURL == "" || ac.TokenURL == "" {
+		return ac, errors.New("the authorization server does not support the device flow")
+	}
+	return ac, nil
+}
+
+// expiresAt turns expires_in into a time; nil when the server gave none.
+func expiresAt(now time.Time, seconds int64) *time.Time {
+	if seconds <= 0 {
+		return nil
+	}
+	t := now.Add(time.Duration(seconds) * time.Second).UTC()
+	return &t
+}
+
+// ---------------------------------------------------
+// device flow
+// ---------------------------------------------------
+
+// deviceLogin runs the device flow and returns the token response. It
+// prints the code for the user and polls until they approve or deny the
+// login, the code expires, or ctx ends.
+func deviceLogin(ctx context.Context, ac AuthConfig) (*tokenResponse, error) {
+	form := url.Values{"client_id": {ac.ClientID}}
+	if ac.Scope != "" {
+		form.Set("scope", ac.Scope)
+	}
+	var da struct {
+		DeviceCode      string `json:"device_code"`
+		UserCode        string `json:"user_code"`
+		VerificationURI string `json:"verification_uri"`
+		VerificationAll string `json:"verification_uri_complete"`
+		ExpiresIn       int64  `json:"expires_in"`
+		Interval        int64  `json:"interval"`
+	}
+	if err := postForm(ac.DeviceURL, form, &da); err != nil {
+		return nil, err
+	}
+	if da.DeviceCode == "" || da.UserCode == "" || da.VerificationURI == "" {
+		return nil, errors.New("incomplete device authorization response")
+	}
+
+	fmt.Println("To log in, open " + accentStyle.Render(da.VerificationURI) + " and enter the code:")
+	fmt.Println()
+	fmt.Println("    " + accentStyle.Render(da.UserCode))
+	fmt.Println()
+	if da.VerificationAll != "" {
+		fmt.Println(mutedStyle.Render("or open " + da.VerificationAll))
+	}
+	fmt.Println(mutedStyle.Render("Waiting for approval… (Ctrl+C to cancel)"))
+
+	interval := devicePollMin
+	if da.Interval > 0 {
+		interval = time.Duration(da.Interval) * time.Second
+	}
+	timeout := deviceMaxTimeout
+	if da.ExpiresIn > 0 {
+		timeout = time.Duration(da.ExpiresIn) * time.Second
+	}
+	deadline := time.Now().Add(timeout)
+	poll := url.Values{
+		"grant_type":  {deviceGrantType},
+		"device_code": {da.DeviceCode},
+		"client_id":   {ac.ClientID},
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, errors.New("cancelled")
+		case <-devicePollWait(interval):
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.New("the code expired before it was approved")
+		}
+		var tr tokenResponse
+		err := postForm(ac.TokenURL, poll, &tr)
+		var oe *oauthError
+		switch {
+		case err == nil:
+			if tr.AccessToken == "" {
+				return nil, errors.New("token response without an access_token")
+			}
+			return &tr, nil
+		case errors.As(err, &oe) && oe.code == "authorization_pending":
+		case errors.As(err, &oe) && oe.code == "slow_down":
+			interval += deviceSlowDown
+		case errors.As(err, &oe) && oe.code == "access_denied":
+			return nil, errors.New("the login was denied")
+		case errors.As(err, &oe) && oe.code == "expired_token":
+			return nil, errors.New("the code expired before it was approved")
+		case errors.Is(err, errOffline):
+			// keep polling through short outages until the code expires
+		default:
+			return nil, err
+		}
+	}
+}
+
+// loginAuthConfig picks the authorization server for a login: --issuer,
+// then the one the profile logged in with last time, then the config. use
+// is false when there is none, and the token is pasted instead.
+func loginAuthConfig(name, issuer, clientID string) (ac AuthConfig, use bool) {
+	cfg, _ := LoadConfig()
+	ac = cfg.Auth
+	if creds, err := loadCredentials(); err == nil && issuer == "" {
+		if pr := creds.Profiles[name]; pr != nil && pr.OAuth != nil && pr.OAuth.Issuer != "" && pr.OAuth.Issuer != ac.Issuer {
+			ac = AuthConfig{Issuer: pr.OAuth.Issuer, ClientID: pr.OAuth.ClientID}
+		}
+	}
+	if issuer != "" && issuer != ac.Issuer {
+		ac = AuthConfig{Issuer: issuer}
+	}
+	if clientID != "" {
+		ac.ClientID = clientID
+	}
+	return ac, ac.Issuer != "" || (ac.DeviceURL != "" && ac.TokenURL != "")
+}
+
+// doDeviceLogin logs profile name in with the device flow and makes it the
+// current profile.
+func doDeviceLogin(name string, ac AuthConfig, server, list string) int {
+	ac, err := resolveEndpoints(ac)
+	if err != nil {
+		fail("auth login: " + err.Error())
+		return 1
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	tr, err := deviceLogin(ctx, ac)
+	if err != nil {
+		fail("auth login: " + err.Error())
+		return 1
+	}
+	if err := loginProfile(name, tr.AccessToken, expiresAt(time.Now(), tr.ExpiresIn), server, list); err != nil {
+		fail("save token: " + err.Error())
+		return 1
+	}
+	creds, err := loadCredentials()
+	if err == nil {
+		creds.Profiles[name].OAuth = &oauthGrant{
+			Issuer:       ac.Issuer,
+			TokenURL:     ac.TokenURL,
+			ClientID:     ac.ClientID,
+			RefreshToken: tr.RefreshToken,
+		}
+		err = saveCredentials(creds)
+	}
+	if err != nil {
+		fail("save token: " + err.Error())
+		return 1
+	}
+	ok("logged in (profile " + name + ")")
+	return 0
+}
+
+// ---------------------------------------------------
+// refresh
+// ---------------------------------------------------
+
+// refreshMu serializes refreshes: refresh tokens are often single-use, so
+// two concurrent refreshes would log the profile out.
+var refreshMu sync.Mutex
+
+// refreshProfile trades the profile's refresh token for a new access token
+// and saves it. A token already renewed by someone else (since stale was
+// read) is returned as is.
+func refreshProfile(name, stale string) (*TokenInfo, error) {
+	refreshMu.Lock()
+	defer refreshMu.Unlock()
+	creds, err := loadCredentials()
+	if err != nil {
+		return nil, err
+	}
+	pr := creds.Profiles[name]
+	if pr == nil || pr.OAuth == nil || pr.OAuth.RefreshToken == "" {
+		return nil, errors.New("no refresh token; run `todo auth login`")
+	}
+	if pr.Token != stale {
+		ti := pr.TokenInfo
+		ti.Profile = name
+		return &ti, nil
+	}
+	var tr tokenResponse
+	err = postForm(pr.OAuth.TokenURL, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {pr.OAuth.RefreshToken},
+		"client_id":     {pr.OAuth.ClientID},
+	}, &tr)
+	if err != nil {
+		return nil, fmt.Errorf("token refresh: %w", err)
+	}
+	if tr.AccessToken == "" {
+		return nil, errors.New("token refresh: response without an access_token")
+	}
+	pr.TokenInfo = TokenInfo{
+		Token:     stripBearer(tr.AccessToken),
+		Source:    "file",
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt(time.Now(), tr.ExpiresIn),
+	}
+	pr.applyClaims()
+	if tr.RefreshToken != "" { // rotated
+		pr.OAuth.RefreshToken = tr.RefreshToken
+	}
+	if err := saveCredentials(creds); err != nil {
+		return nil, err
+	}
+	ti := pr.TokenInfo
+	ti.Profile = name
+	return &ti, nil
+}
+
+// refreshable reports whether ti can be renewed without the user.
+func refreshable(ti *TokenInfo) bool {
+	if ti.Source != "file" {
+		return false
+	}
+	creds, err := loadCredentials()
+	if err != nil {
+		return false
+	}
+	pr := creds.Profiles[ti.Profile]
+	return pr != nil && pr.OAuth != nil && pr.OAuth.RefreshToken != ""
+}
+
+// refreshIfDue renews ti when it expires within tokenRefreshEarly and the
+// profile has a refresh token; otherwise ti is returned unchanged.
+func refreshIfDue(ti *TokenInfo, now time.Time) (*TokenInfo, error) {
+	if d, known := ti.remaining(now); !known || d >= tokenRefreshEarly || !refreshable(ti) {
+		return ti, nil
+	}
+	return refreshProfile(ti.Profile, ti.Token)
+}
+
+// tokenRefresher returns a function the sync client calls on 401, or nil
+// when ti cannot be refreshed (TADA_TOKEN, pasted tokens).
+func tokenRefresher(ti *TokenInfo) func(stale string) (string, error) {
+	if !refreshable(ti) {
+		return nil
+	}
+	name := ti.Profile
+	return func(stale string) (string, error) {
+		nt, err := refreshProfile(name, stale)
+		if err != nil {
+			return "", err
+		}
+		return nt.Token, nil
+	}
+}
@@ -0,0 +1,231 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAuth is a local authorization server (device flow and refresh
+// grant) that also serves /items, answering 401 to any access token but
+// the latest one.
+type fakeAuth struct {
+	mu        sync.Mutex
+	pending   int // token polls to answer authorization_pending
+	slowDown  bool
+	issued    int
+	access    string
+	refresh   string
+	ttl       int
+	refreshes int
+	polls     int
+}
+
+func newFakeAuth(t *testing.T) (*fakeAuth, *httptest.Server) {
+	f := &fakeAuth{ttl: 3600}
+	mux := http.NewServeMux()
+	var ts *httptest.Server
+	mux.HandleFunc("GET /.well-known/oauth-authorization-server", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"issuer":                        ts.URL,
+			"device_authorization_endpoint": ts.URL + "/device",
+			"token_endpoint":                ts.URL + "/token",
+		})
+	})
+	mux.HandleFunc("POST /device", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"device_code": "dev-1", "user_code": "WDJB-MJHT",
+			"verification_uri": ts.URL + "/activate", "expires_in": 600, "interval": 2,
+		})
+	})
+	mux.HandleFunc("POST /token", f.token)
+	mux.HandleFunc("GET /items", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if stripBearer(r.Header.Get("Authorization")) != f.access {
+			writeError(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string][]Item{"items": {}})
+	})
+	ts = httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return f, ts
+}
+
+func (f *fakeAuth) issue(w http.ResponseWriter) {
+	f.issued++
+	f.access = fmt.Sprintf("access-%d", f.issued)
+	f.refresh = fmt.Sprintf("refresh-%d", f.issued)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": f.access, "token_type": "Bearer",
+		"expires_in": f.ttl, "refresh_token": f.refresh,
+	})
+}
+
+func (f *fakeAuth) token(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r.ParseForm()
+	oauthErr := func(code string) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+	}
+	switch r.Form.Get("grant_type") {
+	case deviceGrantType:
+		f.polls++
+		switch {
+		case r.Form.Get("device_code") != "dev-1":
+			oauthErr("invalid_grant")
+		case f.slowDown:
+			f.slowDown = false
+			oauthErr("slow_down")
+		case f.pending > 0:
+			f.pending--
+			oauthErr("authorization_pending")
+		default:
+			f.issue(w)
+		}
+	case "refresh_token":
+		if r.Form.Get("refresh_token") != f.refresh {
+			oauthErr("invalid_grant") // refresh tokens are single-use
+			return
+		}
+		f.refreshes++
+		f.issue(w)
+	default:
+		oauthErr("unsupported_grant_type")
+	}
+}
+
+// instantPolls makes the device flow poll without waiting and records the
+// intervals it asked for.
+func instantPolls(t *testing.T) *[]time.Duration {
+	var waits []time.Duration
+	prev := devicePollWait
+	devicePollWait = func(d time.Duration) <-chan time.Time {
+		waits = append(waits, d)
+		c := make(chan time.Time, 1)
+		c <- time.Now()
+		return c
+	}
+	t.Cleanup(func() { devicePollWait = prev })
+	return &waits
+}
+
+func TestDeviceLoginPollsUntilApproved(t *testing.T) {
+	inTempDir(t)
+	f, ts := newFakeAuth(t)
+	waits := instantPolls(t)
+	f.pending, f.slowDown = 2, true
+
+	if code := doDeviceLogin("work", AuthConfig{Issuer: ts.URL}, ts.URL, ""); code != 0 {
+		t.Fatalf("doDeviceLogin = %d", code)
+	}
+	if f.polls != 4 {
+		t.Fatalf("polls = %d, want 4 (slow_down, 2× pending, success)", f.polls)
+	}
+	want := []time.Duration{2 * time.Second, 7 * time.Second, 7 * time.Second, 7 * time.Second}
+	if fmt.Sprint(*waits) != fmt.Sprint(want) {
+		t.Fatalf("poll intervals %v, want %v", *waits, want)
+	}
+
+	t.Setenv("TADA_PROFILE", "work")
+	ti, err := GetToken()
+	if err != nil || ti == nil || ti.Token != "access-1" {
+		t.Fatalf("stored token %+v, err %v", ti, err)
+	}
+	if d, known := ti.remaining(time.Now()); !known || d < 59*time.Minute {
+		t.Fatalf("expiry not taken from expires_in: %v %v", d, known)
+	}
+	if pr := activeProfile(); pr.OAuth == nil || pr.OAuth.RefreshToken != "refresh-1" || pr.OAuth.TokenURL != ts.URL+"/token" {
+		t.Fatalf("grant not stored: %+v", pr.OAuth)
+	}
+}
+
+func TestDeviceLoginDenied(t *testing.T) {
+	inTempDir(t)
+	_, ts := newFakeAuth(t)
+	instantPolls(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "access_denied"})
+	})
+	deny := httptest.NewServer(mux)
+	defer deny.Close()
+
+	_, err := deviceLogin(context.Background(), AuthConfig{ClientID: "c", DeviceURL: ts.URL + "/device", TokenURL: deny.URL + "/token"})
+	if err == nil || !strings.Contains(err.Error(), "denied") {
+		t.Fatalf("err = %v, want a denial", err)
+	}
+}
+
+// loggedIn logs the current profile in through the fake server.
+func loggedIn(t *testing.T, f *fakeAuth, ts *httptest.Server) *TokenInfo {
+	t.Helper()
+	instantPolls(t)
+	if code := doDeviceLogin(defaultProfile, AuthConfig{Issuer: ts.URL}, ts.URL, ""); code != 0 {
+		t.Fatalf("doDeviceLogin = %d", code)
+	}
+	ti, err := GetToken()
+	if err != nil || ti == nil {
+		t.Fatalf("GetToken: %+v %v", ti, err)
+	}
+	return ti
+}
+
+func TestRefreshOn401(t *testing.T) {
+	inTempDir(t)
+	f, ts := newFakeAuth(t)
+	ti := loggedIn(t, f, ts)
+
+	f.mu.Lock()
+	f.access = "revoked" // the server no longer accepts access-1
+	f.mu.Unlock()
+
+	c := newSyncClient(ts.URL, ti.Token)
+	c.refresh = tokenRefresher(ti)
+	if _, err := c.list(); err != nil {
+		t.Fatalf("list after 401: %v", err)
+	}
+	if f.refreshes != 1 || c.bearer() != "access-2" {
+		t.Fatalf("refreshes %d, token %s; want 1 and access-2", f.refreshes, c.bearer())
+	}
+	ti, _ = GetToken()
+	if ti.Token != "access-2" || activeProfile().OAuth.RefreshToken != "refresh-2" {
+		t.Fatalf("refreshed token not saved: %s / %s", ti.Token, activeProfile().OAuth.RefreshToken)
+	}
+
+	// a token the server keeps refusing is reported, not retried forever
+	f.mu.Lock()
+	f.access = "revoked again"
+	f.mu.Unlock()
+	c.refresh = func(string) (string, error) { return "", fmt.Errorf("refresh refused") }
+	if _, err := c.list(); err == nil {
+		t.Fatal("list succeeded with a refused token")
+	}
+}
+
+func TestRefreshNearExpiry(t *testing.T) {
+	inTempDir(t)
+	f, ts := newFakeAuth(t)
+	f.ttl = 60 // inside tokenRefreshEarly
+	ti := loggedIn(t, f, ts)
+
+	nt, err := refreshIfDue(ti, time.Now())
+	if err != nil || nt.Token != "access-2" || f.refreshes != 1 {
+		t.Fatalf("refreshIfDue: %+v, %v, %d refreshes", nt, err, f.refreshes)
+	}
+
+	f.ttl = 3600
+	if nt, _ = refreshIfDue(nt, time.Now()); f.refreshes != 2 || nt.Token != "access-3" {
+		t.Fatalf("second refresh: %d refreshes, token %s", f.refreshes, nt.Token)
+	}
+	if nt, _ = refreshIfDue(nt, time.Now()); f.refreshes != 2 || nt.Token != "access-3" {
+		t.Fatalf("refreshed a token far from expiry (%d refreshes)", f.refreshes)
+	}
+}
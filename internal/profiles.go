@@ -0,0 +1,283 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Profiles let one person keep several accounts (say, a personal and a
+// company server) in credentials.json and switch between them. The active
+// profile is TADA_PROFILE, else the one last chosen with `todo auth use`.
+
+const defaultProfile = "default"
+
+// Profile is one account: its token, plus the server and list to use.
+type Profile struct {
+	TokenInfo
+	Server string `json:"server,omitempty"` // sync server; overrides sync.url
+	List   string `json:"list,omitempty"`   // list to use when no -l is given
+
+	OAuth *oauthGrant `json:"oauth,omitempty"` // set by a device flow login (oauth.go)
+}
+
+// credentials is the content of credentials.json.
+type credentials struct {
+	Current  string              `json:"current"`
+	Profiles map[string]*Profile `json:"profiles"`
+}
+
+func loadCredentials() (*credentials, error) {
+	p, err := credFilePath()
+	if err != nil {
+		return nil, err
+	}
+	creds := &credentials{Profiles: map[string]*Profile{}}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return creds, nil
+		}
+		return nil, fmt.Errorf("read credentials: %w", err)
+	}
+	var probe struct {
+		Profiles json.RawMessage `json:"profiles"`
+	}
+	if err := json.Unmarshal(b, &probe); err != nil {
+		return nil, fmt.Errorf("parse credentials: %w", err)
+	}
+	if probe.Profiles == nil {
+		// a single token saved by an older build becomes the default profile
+		var ti TokenInfo
+		if err := json.Unmarshal(b, &ti); err != nil {
+			return nil, fmt.Errorf("parse credentials: %w", err)
+		}
+		if ti.Token != "" {
+			creds.Current = defaultProfile
+			creds.Profiles[defaultProfile] = &Profile{TokenInfo: ti}
+			if err := saveCredentials(creds); err != nil {
+				return nil, fmt.Errorf("migrate credentials: %w", err)
+			}
+		}
+		return creds, nil
+	}
+	if err := json.Unmarshal(b, creds); err != nil {
+		return nil, fmt.Errorf("parse credentials: %w", err)
+	}
+	if creds.Profiles == nil {
+		creds.Profiles = map[string]*Profile{}
+	}
+	return creds, nil
+}
+
+func saveCredentials(creds *credentials) error {
+	dir, err := credsDir()
+	if err != nil {
+		return err
+	}
+	// ensure ~/.tada exists with 0700
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("mkdir: %w", err)
+	}
+	b, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	p, _ := credFilePath()
+	// write with 0600 (owner-only)
+	if err := writeFileAtomic(p, b, 0o600); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	return nil
+}
+
+func profileNames(creds *credentials) []string {
+	names := make([]string, 0, len(creds.Profiles))
+	for n := range creds.Profiles {
+		names = append(names, n)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// profileName is the active profile: TADA_PROFILE, else the current one.
+func profileName() string {
+	if env := strings.TrimSpace(os.Getenv("TADA_PROFILE")); env != "" {
+		return env
+	}
+	if creds, err := loadCredentials(); err == nil && creds.Current != "" {
+		return creds.Current
+	}
+	return defaultProfile
+}
+
+// activeProfile returns the active profile, or nil if it has not been
+// created yet.
+func activeProfile() *Profile {
+	creds, err := loadCredentials()
+	if err != nil {
+		return nil
+	}
+	return creds.Profiles[profileName()]
+}
+
+// clearProfileToken forgets a profile's access and refresh tokens. Its
+// server, list and OAuth issuer stay for the next login.
+func clearProfileToken(name string) error {
+	creds, err := loadCredentials()
+	if err != nil {
+		return err
+	}
+	pr := creds.Profiles[name]
+	if pr == nil {
+		return nil
+	}
+	pr.TokenInfo = TokenInfo{}
+	if pr.OAuth != nil {
+		pr.OAuth.RefreshToken = ""
+	}
+	return saveCredentials(creds)
+}
+
+// deleteProfile removes a profile. If it was the current one, "default"
+// (or else the first remaining profile) takes over.
+func deleteProfile(name string) error {
+	creds, err := loadCredentials()
+	if err != nil {
+		return err
+	}
+	if _, has := creds.Profiles[name]; !has {
+		return nil
+	}
+	delete(creds.Profiles, name)
+	if creds.Current == name {
+		creds.Current = ""
+		if _, has := creds.Profiles[defaultProfile]; has {
+			creds.Current = defaultProfile
+		} else if names := profileNames(creds); len(names) > 0 {
+			creds.Current = names[0]
+		}
+	}
+	return saveCredentials(creds)
+}
+
+// ---------------------------------------------------
+// auth subcommands for profiles
+// ---------------------------------------------------
+
+const authUsage = "usage: todo auth <login|logout|status|whoami|use|list>"
+
+const authLogoutUsage = "usage: todo auth logout [--profile name] [--remove]"
+
+const authLoginUsage = "usage: todo auth login [--profile name] [--server URL] [--list name] [--issuer URL [--client-id ID]] [--with-token] [--expires when]"
+
+// profileFlag extracts --profile name from a; the rest is returned.
+func profileFlag(a []string) (name string, rest []string, err error) {
+	for i := 0; i < len(a); i++ {
+		if a[i] == "--profile" {
+			if i+1 >= len(a) || !listNameRE.MatchString(a[i+1]) {
+				return "", nil, errors.New("--profile wants a name (letters, digits, '.', '_' and '-')")
+			}
+			name = a[i+1]
+			i++
+			continue
+		}
+		rest = append(rest, a[i])
+	}
+	return name, rest, nil
+}
+
+// loginProfile saves a login: the token plus, when given, the profile's
+// server and list. The profile becomes the current one.
+func loginProfile(name, token string, expires *time.Time, server, list string) error {
+	if err := setProfileToken(name, token, expires); err != nil {
+		return err
+	}
+	creds, err := loadCredentials()
+	if err != nil {
+		return err
+	}
+	pr := creds.Profiles[name]
+	if server != "" {
+		pr.Server = server
+	}
+	if list != "" {
+		pr.List = list
+	}
+	creds.Current = name
+	return saveCredentials(creds)
+}
+
+func doAuthUse(name string) int {
+	creds, err := loadCredentials()
+	if err != nil {
+		fail("auth: " + err.Error())
+		return 1
+	}
+	if _, has := creds.Profiles[name]; !has {
+		fail("auth use: no profile named " + name)
+		fmt.Fprintln(os.Stderr, mutedStyle.Render("Hint: run `todo auth list`, or `todo auth login --profile "+name+"` to create it"))
+		return 2
+	}
+	creds.Current = name
+	if err := saveCredentials(creds); err != nil {
+		fail("auth: " + err.Error())
+		return 1
+	}
+	ok("using profile " + name)
+	if env := os.Getenv("TADA_PROFILE"); env != "" && env != name {
+		fmt.Println(mutedStyle.Render("TADA_PROFILE=" + env + " still takes precedence in this shell"))
+	}
+	return 0
+}
+
+func doAuthList() int {
+	creds, err := loadCredentials()
+	if err != nil {
+		fail("auth: " + err.Error())
+		return 1
+	}
+	names := profileNames(creds)
+	if len(names) == 0 {
+		fmt.Println(mutedStyle.Render("no profiles"))
+		fmt.Println("Run: todo auth login [--profile name]")
+		return 0
+	}
+	active := profileName()
+	now := time.Now()
+	for _, n := range names {
+		pr := creds.Profiles[n]
+		mark := "  "
+		if n == active {
+			mark = accentStyle.Render("* ")
+		}
+		var info []string
+		if pr.Subject != "" {
+			info = append(info, pr.Subject)
+		}
+		if pr.Server != "" {
+			info = append(info, pr.Server)
+		}
+		if pr.List != "" {
+			info = append(info, "list "+pr.List)
+		}
+		if pr.Token == "" {
+			info = append(info, "logged out")
+		}
+		ti := pr.TokenInfo
+		ti.applyClaims()
+		if d, known := ti.remaining(now); known {
+			if d <= 0 {
+				info = append(info, errorStyle.Render("expired"))
+			} else {
+				info = append(info, "expires in "+fmtRemaining(d))
+			}
+		}
+		fmt.Printf("%s%-16s %s\n", mark, n, mutedStyle.Render(strings.Join(info, " · ")))
+	}
+	return 0
+}
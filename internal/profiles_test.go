@@ -0,0 +1,40 @@
+package internal
+
+import "testing"
+
+// Logging out forgets the token but keeps the profile's server and list;
+// only --remove deletes the profile.
+func TestLogoutKeepsProfile(t *testing.T) {
+	inTempDir(t)
+	if err := loginProfile("work", "tok", nil, "https://tada.example.com", "job"); err != nil {
+		t.Fatal(err)
+	}
+	if err := loginProfile("home", "tok2", nil, "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := doAuthLogout("work", false); code != 0 {
+		t.Fatalf("logout = %d", code)
+	}
+	creds, err := loadCredentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr := creds.Profiles["work"]
+	if pr == nil || pr.Server != "https://tada.example.com" || pr.List != "job" {
+		t.Fatalf("logout lost the profile: %+v", pr)
+	}
+	if pr.Token != "" {
+		t.Fatalf("logout kept the token %q", pr.Token)
+	}
+	if creds.Profiles["home"].Token != "tok2" {
+		t.Fatal("logout touched another profile")
+	}
+
+	if code := doAuthLogout("work", true); code != 0 {
+		t.Fatalf("logout --remove = %d", code)
+	}
+	if creds, _ = loadCredentials(); creds.Profiles["work"] != nil {
+		t.Fatal("--remove kept the profile")
+	}
+}
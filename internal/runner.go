@@ -12,12 +12,28 @@ import (
 // Options tune output behavior from root flags.
 type Options struct {
 	Group bool // list grouped by pending/done (for a future non-TUI list view)
+
+	Board bool           // open the TUI in board view
+	WIP   map[Status]int // board WIP limits per column
+
+	List string // named list to work on (-l); empty means the default list
+
+	Live bool // keep the TUI in sync with the sync server (ls --live)
 }
 
 // ---------------------------------------------------
 // CLI router
 // ---------------------------------------------------
 
+// unscoped are the commands that do not work on one list, so -l,
+// TADA_LIST and the profile's list do not apply to them.
+var unscoped = map[string]bool{
+	"help": true, "-h": true, "--help": true,
+	"lists": true, "list": true,
+	"merge": true, "git": true, "git-merge-driver": true,
+	"auth": true,
+}
+
 func Run(args []string, opt Options) int {
 	if len(args) == 0 {
 		PrintHelp()
@@ -25,12 +41,36 @@ func Run(args []string, opt Options) int {
 	}
 	cmd, a := args[0], args[1:]
 
+	fromProfile := false
+	if opt.List == "" && !unscoped[cmd] {
+		if pr := activeProfile(); pr != nil && pr.List != "" {
+			opt.List, fromProfile = pr.List, true
+		}
+	}
+	if opt.List != "" && !unscoped[cmd] {
+		if err := UseList(opt.List); err != nil {
+			fail(err.Error())
+			if fromProfile {
+				fmt.Fprintln(os.Stderr, mutedStyle.Render("Hint: "+opt.List+" is the list of profile "+profileName()+"; pass -l to use another"))
+			}
+			return 2
+		}
+	}
+
 	switch cmd {
 	case "help", "-h", "--help":
 		PrintHelp()
 		return 0
 
 	case "ls":
+		switch {
+		case len(a) == 0:
+		case len(a) == 1 && a[0] == "--live":
+			opt.Live = true
+		default:
+			fail("usage: todo ls [--live]")
+			return 2
+		}
 		return doList(opt)
 
 	case "add":
@@ -53,33 +93,336 @@ func Run(args []string, opt Options) int {
 		return doToggle(n)
 
 	case "rm":
+		yes := false
+		var idx []int
+		for _, s := range a {
+			if s == "-y" || s == "--yes" {
+				yes = true
+				continue
+			}
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				fail("rm: not a number: " + s)
+				return 2
+			}
+			idx = append(idx, n)
+		}
+		if len(idx) == 0 {
+			fail("usage: todo rm <index...> [--yes]")
+			return 2
+		}
+		return doRemove(idx, yes)
+
+	case "trash":
+		const trashUsage = "usage: todo trash <ls|restore <index>|empty [--yes]>"
+		if len(a) == 0 {
+			fail(trashUsage)
+			return 2
+		}
+		switch {
+		case a[0] == "ls" && len(a) == 1:
+			return doTrashList()
+		case a[0] == "restore" && len(a) == 2:
+			n, err := strconv.Atoi(a[1])
+			if err != nil {
+				fail("trash restore: not a number: " + a[1])
+				return 2
+			}
+			return doTrashRestore(n)
+		case a[0] == "empty" && len(a) == 1:
+			return doTrashEmpty(false)
+		case a[0] == "empty" && len(a) == 2 && (a[1] == "-y" || a[1] == "--yes"):
+			return doTrashEmpty(true)
+		}
+		fail(trashUsage)
+		return 2
+
+	case "mv":
+		return doMoveArgs(a)
+
+	case "lists":
+		return doLists()
+
+	case "list":
+		switch {
+		case len(a) == 2 && a[0] == "create":
+			return doListCreate(a[1])
+		case len(a) == 3 && a[0] == "rename":
+			return doListRename(a[1], a[2])
+		case len(a) >= 2 && a[0] == "delete":
+			yes := len(a) == 3 && (a[2] == "-y" || a[2] == "--yes")
+			if len(a) == 3 && !yes {
+				fail(listUsage)
+				return 2
+			}
+			return doListDelete(a[1], yes)
+		}
+		fail(listUsage)
+		return 2
+
+	case "due", "schedule":
+		if len(a) != 2 {
+			fail("usage: todo " + cmd + " <index> <date|none>")
+			return 2
+		}
+		n, err := strconv.Atoi(a[0])
+		if err != nil {
+			fail(cmd + ": not a number: " + a[0])
+			return 2
+		}
+		kind := "due"
+		if cmd == "schedule" {
+			kind = "scheduled"
+		}
+		return doSetDate(kind, n, a[1])
+
+	case "agenda":
+		days := 7
+		switch {
+		case len(a) == 0:
+		case len(a) == 2 && a[0] == "--days":
+			n, err := strconv.Atoi(a[1])
+			if err != nil || n < 1 {
+				fail("agenda: --days wants a positive number: " + a[1])
+				return 2
+			}
+			days = n
+		default:
+			fail("usage: todo agenda [--days N]")
+			return 2
+		}
+		return doAgenda(days)
+
+	case "board":
+		opt.Board = true
+		switch {
+		case len(a) == 0:
+		case len(a) == 2 && a[0] == "--wip":
+			wip, err := parseWIP(a[1])
+			if err != nil {
+				fail("board: " + err.Error())
+				return 2
+			}
+			opt.WIP = wip
+		default:
+			fail("usage: todo board [--wip doing=3,todo=10]")
+			return 2
+		}
+		return doList(opt)
+
+	case "start":
 		if len(a) != 1 {
-			fail("usage: todo rm <index>")
+			fail("usage: todo start <index>")
 			return 2
 		}
 		n, err := strconv.Atoi(a[0])
 		if err != nil {
-			fail("rm: not a number: " + a[0])
+			fail("start: not a number: " + a[0])
 			return 2
 		}
-		return doRemove(n)
+		return doStart(n)
+
+	case "stop":
+		if len(a) != 0 {
+			fail("usage: todo stop")
+			return 2
+		}
+		return doStop()
+
+	case "project", "estimate", "priority":
+		if len(a) != 2 {
+			arg := map[string]string{"project": "name", "estimate": "duration", "priority": "high|medium|low"}[cmd]
+			fail("usage: todo " + cmd + " <index> <" + arg + "|none>")
+			return 2
+		}
+		n, err := strconv.Atoi(a[0])
+		if err != nil {
+			fail(cmd + ": not a number: " + a[0])
+			return 2
+		}
+		return doSetField(cmd, n, a[1])
+
+	case "tag":
+		if len(a) < 2 {
+			fail("usage: todo tag <index> <tag|+tag|-tag...>")
+			return 2
+		}
+		n, err := strconv.Atoi(a[0])
+		if err != nil {
+			fail("tag: not a number: " + a[0])
+			return 2
+		}
+		return doTag(n, a[1:])
+
+	case "stats":
+		weeks, asJSON := 8, false
+		for i := 0; i < len(a); i++ {
+			switch {
+			case a[i] == "--json":
+				asJSON = true
+			case a[i] == "--weeks" && i+1 < len(a):
+				n, err := strconv.Atoi(a[i+1])
+				if err != nil || n < 1 {
+					fail("stats: --weeks wants a positive number: " + a[i+1])
+					return 2
+				}
+				weeks = n
+				i++
+			default:
+				fail("usage: todo stats [--weeks N] [--json]")
+				return 2
+			}
+		}
+		return doStats(weeks, asJSON)
+
+	case "import":
+		var format, path string
+		dryRun := false
+		for i := 0; i < len(a); i++ {
+			switch {
+			case a[i] == "--from" && i+1 < len(a):
+				format = a[i+1]
+				i++
+			case a[i] == "--dry-run" || a[i] == "-n":
+				dryRun = true
+			case path == "" && (a[i] == "-" || !strings.HasPrefix(a[i], "-")):
+				path = a[i]
+			default:
+				fail(importUsage)
+				return 2
+			}
+		}
+		if format == "" || path == "" {
+			fail(importUsage)
+			return 2
+		}
+		return doImport(format, path, dryRun)
+
+	case "export":
+		var format, out string
+		for i := 0; i < len(a); i++ {
+			switch {
+			case (a[i] == "--format" || a[i] == "-f") && i+1 < len(a):
+				format = a[i+1]
+				i++
+			case (a[i] == "-o" || a[i] == "--output") && i+1 < len(a):
+				out = a[i+1]
+				i++
+			default:
+				fail(exportUsage)
+				return 2
+			}
+		}
+		if format == "" {
+			fail(exportUsage)
+			return 2
+		}
+		return doExport(format, out)
+
+	case "report":
+		ropt, err := parseReportArgs(a, time.Now())
+		if err != nil {
+			fail(err.Error())
+			return 2
+		}
+		return doReport(ropt)
+
+	case "focus":
+		cfg, err := LoadConfig()
+		if err != nil {
+			fail(err.Error())
+			return 1
+		}
+		focusLen, breakLen, err := cfg.Focus.durations()
+		if err != nil {
+			fail(err.Error())
+			return 1
+		}
+		keys, err := newKeyMap(cfg.Keymap)
+		if err != nil {
+			fail(err.Error())
+			return 1
+		}
+		n, focusLen, breakLen, err := parseFocusArgs(a, focusLen, breakLen)
+		if err != nil {
+			fail(err.Error())
+			return 2
+		}
+		return doFocus(n, focusLen, breakLen, keys)
+
+	case "sync":
+		return doSync(a)
+
+	case "merge":
+		var files []string
+		out := ""
+		for i := 0; i < len(a); i++ {
+			if (a[i] == "-o" || a[i] == "--output") && i+1 < len(a) {
+				out = a[i+1]
+				i++
+				continue
+			}
+			files = append(files, a[i])
+		}
+		if len(files) != 2 {
+			fail(mergeUsage)
+			return 2
+		}
+		return doMerge(files[0], files[1], out)
+
+	case "git":
+		if len(a) == 1 && a[0] == "install" {
+			return doGitInstall()
+		}
+		fail(gitUsage)
+		return 2
+
+	case "git-merge-driver":
+		// git passes %O %A %B, optionally followed by %P
+		if len(a) < 3 || len(a) > 4 {
+			fail("usage: todo git-merge-driver <ancestor> <ours> <theirs>")
+			return 2
+		}
+		return doGitMergeDriver(a[0], a[1], a[2])
+
+	case "serve":
+		addr, err := parseServeArgs(a)
+		if err != nil {
+			fail(err.Error())
+			return 2
+		}
+		return doServe(addr)
 
 	case "auth":
 		if len(a) == 0 {
-			fail("usage: todo auth <login|logout|status|whoami>")
+			fail(authUsage)
 			return 2
 		}
 		switch a[0] {
 		case "login":
-			return doAuthLogin()
+			return doAuthLogin(a[1:])
 		case "logout":
-			return doAuthLogout()
+			name, rest, err := profileFlag(a[1:])
+			remove := len(rest) == 1 && rest[0] == "--remove"
+			if err != nil || len(rest) > 0 && !remove {
+				fail(authLogoutUsage)
+				return 2
+			}
+			return doAuthLogout(name, remove)
 		case "status":
 			return doAuthStatus()
 		case "whoami":
 			return doAuthWhoAmI()
+		case "use":
+			if len(a) != 2 {
+				fail("usage: todo auth use <profile>")
+				return 2
+			}
+			return doAuthUse(a[1])
+		case "list":
+			return doAuthList()
 		default:
-			fail("usage: todo auth <login|logout|status|whoami>")
+			fail(authUsage)
 			return 2
 		}
 	}
@@ -94,20 +437,78 @@ func PrintHelp() {
 	fmt.Printf(`todo - a tiny CLI
 
 Usage:
-  todo <subcommand> [args]
+  todo [-l list] <subcommand> [args]
 
 Subcommands:
   add <title...>     Add a new item (title can be multiple words)
-  ls                 List items (interactive TUI)
+  ls [--live]        List items (interactive TUI); --live follows the sync server
+  board [--wip doing=3]
+                     Kanban board (Todo / Doing / Done) with optional WIP limits
   done <index>       Toggle done for item at 1-based index
-  rm <index>         Remove item at 1-based index
+  rm <index...> [--yes]
+                     Move items to the trash (asks first for several if configured)
+  trash <ls|restore <index>|empty>   Deleted items; purged after retention
+  mv <index> <position|--before index|--after index>
+                     Move item to a new 1-based position
+  mv <index...> --to <list>     Move items to another list
+  import --from todotxt|taskwarrior|markdown|csv <file|-> [--dry-run]
+                     Import items, skipping titles already in the list
+  export --format todotxt|markdown|csv|html|ics [-o file]
+                     Write the list in another format (stdout by default)
+  lists              Show the named lists
+  list <create <name>|rename <old> <new>|delete <name> [--yes]>
+                     Manage named lists (deleting one trashes its items)
+  due <index> <date|none>       Set or clear the due date (YYYY-MM-DD, today, +3d)
+  schedule <index> <date|none>  Set or clear the scheduled date
+  agenda [--days N]  Items by day for the next N days (default 7), overdue first
+  tag <index> <tag|-tag...>     Add or remove tags
+  priority <index> <high|medium|low|none>  Set or clear the priority
+  stats [--weeks N] [--json]    Completion rate, weekly trend, streak, tag/priority breakdown
+  start <index>      Start tracking time on an item (stops any running timer)
+  stop               Stop the running timer
+  project <index> <name|none>   Set or clear the item's project
+  estimate <index> <duration|none>  Set or clear the estimate (90m, 2h)
+  report [--day|--week|--month|--since date] [--by item|project|day] [--csv|--timeclock]
+                     Tracked time totals, estimates vs actuals, or an export
+  focus <index> [25m] [--break 5m]
+                     Focus timer on an item; completed sessions are recorded
+  sync <push|pull [--theirs]|status> [--url URL]
+                     Sync the list with a tada server (changes queue while offline)
+  sync --git         Commit the list, then pull and push its git repository
+  merge <a.json> <b.json> [-o file]
+                     Merge two diverged copies of the data file (stdout by default)
+  git install        Register the todos.json merge driver in this git repository
+  git-merge-driver %%O %%A %%B  Item-aware three-way merge (called by git)
+  serve [--addr host:port | --port N]
+                     Serve the list over an HTTP/JSON API (sync server; /openapi.json)
   auth <login|logout|status|whoami>   Token authentication
+  auth login [--profile name] [--server URL] [--list name] [--issuer URL [--client-id ID]]
+                     Log in to a named profile (account) and make it current;
+                     with an authorization server, through the OAuth device flow
+  auth login --with-token [--expires 30d|date] < token
+                     Read the token from stdin (CI); --expires sets its expiry
+  auth <use <profile>|list>     Switch profile (or set TADA_PROFILE), list profiles
 
 Examples:
   todo add "Buy milk"
   todo ls
   todo done 2
   todo rm 3
+  todo mv 4 1
+  todo mv 2 --after 5
+  todo list create work
+  todo -l work add "Write report"
+  todo mv 3 --to work
+  todo due 2 tomorrow
+  todo agenda --days 14
+  todo focus 1 50m --break 10m
+  todo report --week --by project
+  todo tag 2 work +urgent
+  todo stats --json
+  todo report --timeclock > time.timeclock
+  todo -l work serve --port 9000
+  todo auth login --profile work --server https://tada.example.com
+  echo "$TADA_CI_TOKEN" | todo auth login --with-token --expires 7d
 `)
 }
 
@@ -115,32 +516,96 @@ Examples:
 // Auth subcommands (use functions from auth.go)
 // ---------------------------------------------------
 
-func doAuthLogin() int {
-	fmt.Print("Paste your token: ")
-	var token string
-	if _, err := fmt.Scanln(&token); err != nil {
+func doAuthLogin(a []string) int {
+	name, rest, err := profileFlag(a)
+	if err != nil {
+		fail(err.Error())
+		return 2
+	}
+	var server, list, issuer, clientID string
+	var expires *time.Time
+	withToken := false
+	for i := 0; i < len(rest); i++ {
+		switch {
+		case rest[i] == "--with-token":
+			withToken = true
+		case rest[i] == "--expires" && i+1 < len(rest):
+			t, err := parseExpires(rest[i+1], time.Now())
+			if err != nil {
+				fail(err.Error())
+				return 2
+			}
+			expires = &t
+			i++
+		case rest[i] == "--issuer" && i+1 < len(rest):
+			issuer = rest[i+1]
+			i++
+		case rest[i] == "--client-id" && i+1 < len(rest):
+			clientID = rest[i+1]
+			i++
+		case rest[i] == "--server" && i+1 < len(rest):
+			server = rest[i+1]
+			i++
+		case rest[i] == "--list" && i+1 < len(rest):
+			list = rest[i+1]
+			if !listNameRE.MatchString(list) {
+				fail("invalid list name: " + list)
+				return 2
+			}
+			i++
+		default:
+			fail(authLoginUsage)
+			return 2
+		}
+	}
+	if name == "" {
+		name = profileName()
+	}
+	if ac, use := loginAuthConfig(name, issuer, clientID); use && !withToken {
+		if expires != nil {
+			fail("--expires applies to a pasted token (the authorization server sets the expiry)")
+			return 2
+		}
+		return doDeviceLogin(name, ac, server, list)
+	}
+	token, err := readToken(os.Stdin, withToken)
+	if err != nil {
 		fail("read token: " + err.Error())
 		return 1
 	}
-	if err := SetToken(token, nil); err != nil {
+	if err := loginProfile(name, token, expires, server, list); err != nil {
 		fail("save token: " + err.Error())
 		return 1
 	}
-	ok("logged in")
+	ok("logged in (profile " + name + ")")
 	return 0
 }
 
-func doAuthLogout() int {
-	ti, _ := GetToken()
-	if ti != nil && ti.Source == "env" {
-		ok("token is provided by TADA_TOKEN env var (nothing to delete)")
+// doAuthLogout forgets the profile's token; with remove it deletes the
+// profile, server and list included.
+func doAuthLogout(name string, remove bool) int {
+	if name == "" {
+		ti, _ := GetToken()
+		if ti != nil && ti.Source == "env" && !remove {
+			ok("token is provided by TADA_TOKEN env var (nothing to delete)")
+			return 0
+		}
+		name = profileName()
+	}
+	if remove {
+		if err := deleteProfile(name); err != nil {
+			fail("logout: " + err.Error())
+			return 1
+		}
+		ok("logged out and removed profile " + name)
 		return 0
 	}
-	if err := DeleteToken(); err != nil {
+	if err := clearProfileToken(name); err != nil {
 		fail("logout: " + err.Error())
 		return 1
 	}
-	ok("logged out")
+	ok("logged out (profile " + name + ")")
+	fmt.Println(mutedStyle.Render("Hint: the profile keeps its server and list; `todo auth logout --remove` deletes it"))
 	return 0
 }
 
@@ -151,13 +616,38 @@ func doAuthStatus() int {
 		fmt.Println("Run: todo auth login")
 		return 0
 	}
+	fmt.Printf("profile: %s\n", ti.Profile)
 	fmt.Printf("source: %s\n", ti.Source)
-	if ti.ExpiresAt != nil {
-		fmt.Printf("expires: %s\n", ti.ExpiresAt.UTC().Format(time.RFC3339))
-	} else {
+	if pr := activeProfile(); pr != nil && pr.Server != "" {
+		fmt.Printf("server: %s\n", pr.Server)
+	}
+	if pr := activeProfile(); pr != nil && pr.List != "" {
+		fmt.Printf("list: %s\n", pr.List)
+	}
+	if ti.Subject != "" {
+		fmt.Printf("subject: %s\n", ti.Subject)
+	}
+	if len(ti.Audience) > 0 {
+		fmt.Printf("audience: %s\n", strings.Join(ti.Audience, ", "))
+	}
+	if ti.IssuedAt != nil {
+		fmt.Printf("issued: %s\n", ti.IssuedAt.UTC().Format(time.RFC3339))
+	}
+	now := time.Now()
+	switch d, known := ti.remaining(now); {
+	case !known:
 		fmt.Println("expires: (unknown)")
+	case d <= 0:
+		fmt.Printf("expires: %s %s\n", ti.ExpiresAt.UTC().Format(time.RFC3339), errorStyle.Render("(expired "+fmtRemaining(d)+" ago)"))
+	case d < tokenExpiryWarn:
+		fmt.Printf("expires: %s %s\n", ti.ExpiresAt.UTC().Format(time.RFC3339), pendingStyle.Render("(in "+fmtRemaining(d)+")"))
+	default:
+		fmt.Printf("expires: %s %s\n", ti.ExpiresAt.UTC().Format(time.RFC3339), mutedStyle.Render("(in "+fmtRemaining(d)+")"))
 	}
-	fmt.Println("env override: TADA_TOKEN")
+	if ti.Source == "file" && refreshable(ti) {
+		fmt.Println("refresh: automatic (OAuth device login)")
+	}
+	fmt.Println("env overrides: TADA_TOKEN, TADA_PROFILE")
 	return 0
 }
 
@@ -202,13 +692,28 @@ func decodeB64URL(s string) (string, error) {
 	return string(dec), nil
 }
 
-// Require a token for future networked commands.
+// Require a token for networked commands. Tokens about to expire are
+// refreshed when the profile has a refresh token; otherwise expired ones
+// are refused and ones about to expire get a warning.
 func ensureAuth() (*TokenInfo, int) {
 	ti, _ := GetToken()
 	if ti == nil || strings.TrimSpace(ti.Token) == "" {
 		fail("no token found. Set TADA_TOKEN or run `todo auth login`")
 		return nil, 2
 	}
+	if nt, err := refreshIfDue(ti, time.Now()); err != nil {
+		warn(err.Error())
+	} else {
+		ti = nt
+	}
+	w, err := ti.checkExpiry(time.Now())
+	if err != nil {
+		fail(err.Error() + ". Run `todo auth login` for a new one")
+		return nil, 2
+	}
+	if w != "" && !refreshable(ti) {
+		warn(w + "; run `todo auth login` to renew it")
+	}
 	return ti, 0
 }
 
@@ -222,8 +727,13 @@ func doList(opt Options) int {
 		fail("load: " + err.Error())
 		return 1
 	}
-	// The interactive TUI (now defined in tui.go). It will save on quit if changed.
-	if err := runInteractiveList(items, opt); err != nil {
+	cfg, err := LoadConfig()
+	if err != nil {
+		fail("config: " + err.Error())
+		return 1
+	}
+	// The interactive TUI (now defined in tui.go). It saves changes as they happen.
+	if err := runInteractiveList(items, opt, cfg); err != nil {
 		fail("tui: " + err.Error())
 		return 1
 	}
@@ -241,7 +751,7 @@ func doAdd(title string) int {
 		fail("add: empty title")
 		return 2
 	}
-	items = append(items, Item{Title: title})
+	items = append(items, newItem(title))
 	if err := Save(items); err != nil {
 		fail("save: " + err.Error())
 		return 1
@@ -263,6 +773,8 @@ func doToggle(userIndex int) int {
 	}
 	idx := userIndex - 1
 	items[idx].Done = !items[idx].Done
+	items[idx].CompletedAt = completionTime(items[idx].Done)
+	items[idx].normalize()
 	if err := Save(items); err != nil {
 		fail("save: " + err.Error())
 		return 1
@@ -271,23 +783,121 @@ func doToggle(userIndex int) int {
 	return 0
 }
 
-func doRemove(userIndex int) int {
+const mvUsage = "usage: todo mv <index> <position|--before index|--after index> | todo mv <index...> --to <list>"
+
+// doMoveArgs parses `mv` arguments and reorders the item.
+func doMoveArgs(a []string) int {
+	if n := len(a); n >= 3 && a[n-2] == "--to" {
+		var idx []int
+		for _, s := range a[:n-2] {
+			i, err := strconv.Atoi(s)
+			if err != nil {
+				fail("mv: not a number: " + s)
+				return 2
+			}
+			idx = append(idx, i)
+		}
+		return doMoveToList(idx, a[n-1])
+	}
+	var src, dst string
+	rel := ""
+	switch {
+	case len(a) == 2:
+		src, dst = a[0], a[1]
+	case len(a) == 3 && (a[1] == "--before" || a[1] == "--after"):
+		src, rel, dst = a[0], a[1], a[2]
+	default:
+		fail(mvUsage)
+		return 2
+	}
+	from, err := strconv.Atoi(src)
+	if err != nil {
+		fail("mv: not a number: " + src)
+		return 2
+	}
+	to, err := strconv.Atoi(dst)
+	if err != nil {
+		fail("mv: not a number: " + dst)
+		return 2
+	}
+	return doMove(from, to, rel)
+}
+
+// doMove moves the item at userIndex. Without rel, target is the final
+// 1-based position; with "--before"/"--after" it is the index of the item
+// to place it next to (as numbered before the move).
+func doMove(userIndex, target int, rel string) int {
 	items, err := Load()
 	if err != nil {
 		fail("load: " + err.Error())
 		return 1
 	}
-	if userIndex < 1 || userIndex > len(items) {
-		fail(fmt.Sprintf("index out of range: have %d, got %d", len(items), userIndex))
-		fmt.Fprintln(os.Stderr, mutedStyle.Render("Hint: run `todo ls` to see valid indexes"))
-		return 2
+	for _, n := range []int{userIndex, target} {
+		if n < 1 || n > len(items) {
+			fail(fmt.Sprintf("index out of range: have %d, got %d", len(items), n))
+			fmt.Fprintln(os.Stderr, mutedStyle.Render("Hint: run `todo ls` to see valid indexes"))
+			return 2
+		}
 	}
-	idx := userIndex - 1
-	items = append(items[:idx], items[idx+1:]...)
+	from, to := userIndex-1, target-1
+	if rel != "" {
+		if from == to {
+			fail("mv: cannot move an item relative to itself")
+			return 2
+		}
+		// account for the gap left by removing the source item
+		if from < to {
+			to--
+		}
+		if rel == "--after" {
+			to++
+		}
+	}
+	items = moveItem(items, from, to)
 	if err := Save(items); err != nil {
 		fail("save: " + err.Error())
 		return 1
 	}
-	ok("removed")
+	ok("moved")
+	return 0
+}
+
+func doRemove(userIndexes []int, yes bool) int {
+	items, err := Load()
+	if err != nil {
+		fail("load: " + err.Error())
+		return 1
+	}
+	drop := map[int]bool{}
+	for _, n := range userIndexes {
+		if n < 1 || n > len(items) {
+			fail(fmt.Sprintf("index out of range: have %d, got %d", len(items), n))
+			fmt.Fprintln(os.Stderr, mutedStyle.Render("Hint: run `todo ls` to see valid indexes"))
+			return 2
+		}
+		drop[n-1] = true
+	}
+	if len(drop) > 1 && !yes {
+		cfg, err := LoadConfig()
+		if err != nil {
+			fail("config: " + err.Error())
+			return 1
+		}
+		if cfg.Trash.ConfirmBulk && !confirm(fmt.Sprintf("Delete %d items?", len(drop))) {
+			fail("rm: cancelled (pass --yes to skip the prompt)")
+			return 2
+		}
+	}
+	out := make([]Item, 0, len(items))
+	for i, it := range items {
+		if !drop[i] {
+			out = append(out, it)
+		}
+	}
+	if err := SaveTrashing(items, out); err != nil {
+		fail("save: " + err.Error())
+		return 1
+	}
+	ok(fmt.Sprintf("moved %d to trash", len(drop)))
 	return 0
 }
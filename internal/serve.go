@@ -0,0 +1,574 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// `todo serve` exposes the active list over the HTTP/JSON API that
+// `todo sync` speaks (see sync.go). Every item carries a revision: the
+// server bumps Item.Rev on each write and uses it as the item's ETag, so
+// clients send If-Match to avoid overwriting changes they have not seen.
+
+const (
+	defaultServeAddr = "127.0.0.1:8080"
+	shutdownTimeout  = 5 * time.Second
+	maxBodyBytes     = 1 << 20
+)
+
+type server struct {
+	mu    sync.Mutex // serialises load-modify-save of the store
+	token string
+
+	// known is the content of each item when the server last saw it. An
+	// item that differs with the same Rev was edited outside the API (CLI,
+	// TUI) and gets a new revision so stale If-Match headers still fail.
+	known  map[string]Item
+	primed bool // known holds the first load; changes after it are events
+
+	events *eventHub // see events.go
+}
+
+func newServer(token string) *server {
+	return &server{token: token, known: map[string]Item{}, events: newEventHub()}
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /openapi.json", s.openAPI)
+	mux.Handle("GET /items", s.auth(s.listItems))
+	mux.Handle("POST /items", s.auth(s.createItem))
+	mux.Handle("GET /items/{id}", s.auth(s.getItem))
+	mux.Handle("PATCH /items/{id}", s.auth(s.patchItem))
+	mux.Handle("DELETE /items/{id}", s.auth(s.deleteItem))
+	mux.Handle("GET /events", s.auth(s.streamEvents))
+	return mux
+}
+
+// auth requires the bearer token the server was started with.
+func (s *server) auth(h http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := stripBearer(r.Header.Get("Authorization"))
+		if got == "" || subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="tada"`)
+			writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+		h(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+func writeItem(w http.ResponseWriter, status int, it Item) {
+	w.Header().Set("ETag", etag(it.Rev))
+	writeJSON(w, status, withoutList(it))
+}
+
+// load reads the list and assigns revisions to items that have none or
+// were edited outside the API. Callers hold s.mu.
+func (s *server) load() ([]Item, error) {
+	items, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	bumped := false
+	for i := range items {
+		it := &items[i]
+		k, seen := s.known[it.ID]
+		switch {
+		case it.Rev == 0:
+			it.Rev = 1
+			bumped = true
+		case seen && k.Rev == it.Rev && !sameItem(k, *it):
+			it.Rev++
+			bumped = true
+		}
+	}
+	if bumped {
+		if err := Save(items); err != nil {
+			return nil, err
+		}
+	}
+	s.remember(items)
+	return items, nil
+}
+
+// save writes the list (deleted items go to the trash) and remembers it.
+func (s *server) save(prev, items []Item) error {
+	if err := SaveTrashing(prev, items); err != nil {
+		return err
+	}
+	s.remember(items)
+	return nil
+}
+
+// remember records items as the current state and publishes how they
+// differ from the previous one.
+func (s *server) remember(items []Item) {
+	if s.primed {
+		s.events.publish(diffEvents(s.known, items))
+	}
+	s.primed = true
+	clear(s.known)
+	for _, it := range items {
+		s.known[it.ID] = it
+	}
+}
+
+// ifMatch reports whether the request's If-Match (if any) matches rev.
+func ifMatch(r *http.Request, rev int) bool {
+	h := strings.TrimSpace(r.Header.Get("If-Match"))
+	if h == "" || h == "*" {
+		return true
+	}
+	for _, tag := range strings.Split(h, ",") {
+		if strings.TrimSpace(tag) == etag(rev) {
+			return true
+		}
+	}
+	return false
+}
+
+func findItem(items []Item, id string) int {
+	for i, it := range items {
+		if it.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *server) listItems(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	items, err := s.load()
+	s.mu.Unlock()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	out := make([]Item, len(items))
+	for i, it := range items {
+		out[i] = withoutList(it)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": out})
+}
+
+func (s *server) getItem(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	items, err := s.load()
+	s.mu.Unlock()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	i := findItem(items, r.PathValue("id"))
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "no such item")
+		return
+	}
+	if r.Header.Get("If-None-Match") == etag(items[i].Rev) {
+		w.Header().Set("ETag", etag(items[i].Rev))
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeItem(w, http.StatusOK, items[i])
+}
+
+func decodeBody(r *http.Request, dst any) error {
+	dec := json.NewDecoder(io.LimitReader(r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return fmt.Errorf("bad JSON body: %w", err)
+	}
+	return nil
+}
+
+func (s *server) createItem(w http.ResponseWriter, r *http.Request) {
+	var it Item
+	if err := decodeBody(r, &it); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if strings.TrimSpace(it.Title) == "" {
+		writeError(w, http.StatusUnprocessableEntity, "title is required")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items, err := s.load()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// clients may pick the ID (sync keeps IDs stable across replicas)
+	if it.ID == "" {
+		it.ID = newID()
+	} else if j := findItem(items, it.ID); j >= 0 {
+		// a retried create that already went through is not a conflict
+		same := it
+		same.Rev, same.List = items[j].Rev, items[j].List
+		same.normalize()
+		if sameItem(same, items[j]) {
+			writeItem(w, http.StatusOK, items[j])
+			return
+		}
+		writeError(w, http.StatusConflict, "an item with this id exists")
+		return
+	}
+	it.Rev = 1
+	if it.CreatedAt == nil {
+		now := time.Now()
+		it.CreatedAt = &now
+	}
+	it.normalize()
+	next := append(append([]Item(nil), items...), it)
+	if err := s.save(items, next); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Location", itemPath(it.ID))
+	writeItem(w, http.StatusCreated, it)
+}
+
+// patchItem applies the body as a JSON merge patch (RFC 7396) on the
+// top-level fields: present fields replace the stored ones and null clears
+// them; id and rev cannot be changed.
+func (s *server) patchItem(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items, err := s.load()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	i := findItem(items, r.PathValue("id"))
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "no such item")
+		return
+	}
+	old := items[i]
+	if !ifMatch(r, old.Rev) {
+		w.Header().Set("ETag", etag(old.Rev))
+		writeError(w, http.StatusPreconditionFailed, "item changed since revision in If-Match")
+		return
+	}
+	it, err := mergePatch(old, body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad JSON body: "+err.Error())
+		return
+	}
+	if strings.TrimSpace(it.Title) == "" {
+		writeError(w, http.StatusUnprocessableEntity, "title is required")
+		return
+	}
+	it.ID, it.Rev, it.List = old.ID, old.Rev, old.List
+	if jsonHas(body, "status") && !jsonHas(body, "done") {
+		// normalize treats Done as authoritative, so follow the new status
+		it.Done = it.Status == StatusDone
+	}
+	if it.Done != old.Done && sameTime(it.CompletedAt, old.CompletedAt) {
+		it.CompletedAt = completionTime(it.Done)
+	}
+	it.normalize()
+	if sameItem(it, old) {
+		writeItem(w, http.StatusOK, old)
+		return
+	}
+	it.Rev++
+	next := append([]Item(nil), items...)
+	next[i] = it
+	if err := s.save(items, next); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeItem(w, http.StatusOK, it)
+}
+
+func (s *server) deleteItem(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items, err := s.load()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	i := findItem(items, r.PathValue("id"))
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "no such item")
+		return
+	}
+	if !ifMatch(r, items[i].Rev) {
+		w.Header().Set("ETag", etag(items[i].Rev))
+		writeError(w, http.StatusPreconditionFailed, "item changed since revision in If-Match")
+		return
+	}
+	next := append(append([]Item(nil), items[:i]...), items[i+1:]...)
+	if err := s.save(items, next); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// mergePatch applies a JSON merge patch to the top-level fields of it. Unknown
+// fields are refused.
+func mergePatch(it Item, patch []byte) (Item, error) {
+	var p map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return Item{}, err
+	}
+	var fields map[string]json.RawMessage
+	b, _ := json.Marshal(it)
+	json.Unmarshal(b, &fields)
+	for k, v := range p {
+		if bytes.Equal(bytes.TrimSpace(v), []byte("null")) {
+			delete(fields, k)
+		} else {
+			fields[k] = v
+		}
+	}
+	b, _ = json.Marshal(fields)
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	var out Item
+	if err := dec.Decode(&out); err != nil {
+		return Item{}, err
+	}
+	return out, nil
+}
+
+// jsonHas reports whether the top-level JSON object has key.
+func jsonHas(body []byte, key string) bool {
+	var m map[string]json.RawMessage
+	if json.Unmarshal(body, &m) != nil {
+		return false
+	}
+	_, has := m[key]
+	return has
+}
+
+func (s *server) openAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	io.WriteString(w, openAPIDoc)
+}
+
+// ---------------------------------------------------
+// serve command
+// ---------------------------------------------------
+
+func doServe(addr string) int {
+	ti, code := ensureAuth()
+	if code != 0 {
+		return code
+	}
+	s := newServer(stripBearer(strings.TrimSpace(ti.Token)))
+	s.mu.Lock()
+	_, err := s.load()
+	s.mu.Unlock()
+	if err != nil {
+		fail("serve: " + err.Error())
+		return 1
+	}
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	srv.RegisterOnShutdown(s.events.close) // Shutdown does not wait for streams
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go s.watch(ctx)
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	ok(fmt.Sprintf("serving list %s on http://%s (Ctrl+C to stop)", activeList, addr))
+
+	select {
+	case err := <-errc:
+		fail("serve: " + err.Error())
+		return 1
+	case <-ctx.Done():
+	}
+	// let in-flight requests (and their saves) finish
+	sctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(sctx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		fail("serve: shutdown: " + err.Error())
+		return 1
+	}
+	ok("server stopped")
+	return 0
+}
+
+func parseServeArgs(a []string) (addr string, err error) {
+	addr = defaultServeAddr
+	for i := 0; i < len(a); i++ {
+		switch {
+		case a[i] == "--addr" && i+1 < len(a):
+			addr = a[i+1]
+			i++
+		case a[i] == "--port" && i+1 < len(a):
+			if _, err := strconv.Atoi(a[i+1]); err != nil {
+				return "", fmt.Errorf("serve: bad port %q", a[i+1])
+			}
+			addr = "127.0.0.1:" + a[i+1]
+			i++
+		default:
+			return "", errors.New("usage: todo serve [--addr host:port | --port N]")
+		}
+	}
+	return addr, nil
+}
+
+// openAPIDoc describes the API served above (OpenAPI 3.0).
+const openAPIDoc = `{
+  "openapi": "3.0.3",
+  "info": {
+    "title": "tada",
+    "version": "1",
+    "description": "Items of one tada list. Each item has a revision (rev) that the server bumps on every change and returns as the ETag; send it back in If-Match to update or delete only what you have seen."
+  },
+  "security": [{"bearer": []}],
+  "paths": {
+    "/items": {
+      "get": {
+        "summary": "List items",
+        "responses": {
+          "200": {"description": "All items in list order", "content": {"application/json": {"schema": {"type": "object", "properties": {"items": {"type": "array", "items": {"$ref": "#/components/schemas/Item"}}}}}}},
+          "401": {"$ref": "#/components/responses/Error"}
+        }
+      },
+      "post": {
+        "summary": "Create an item",
+        "description": "The id may be chosen by the client; it is generated otherwise. rev is ignored and starts at 1.",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Item"}}}},
+        "responses": {
+          "201": {"description": "Created", "headers": {"ETag": {"$ref": "#/components/headers/ETag"}, "Location": {"schema": {"type": "string"}}}, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Item"}}}},
+          "200": {"description": "The same item was already created (retried request)", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Item"}}}},
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Error"},
+          "409": {"$ref": "#/components/responses/Error"},
+          "422": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/events": {
+      "get": {
+        "summary": "Stream item changes (server-sent events)",
+        "description": "Each event is named create, update or delete and its data is a JSON object with seq, type, id, rev and (except for deletes) item. Reconnect with Last-Event-ID to get missed events; a reset event means they are gone and /items should be fetched again.",
+        "parameters": [{"name": "Last-Event-ID", "in": "header", "schema": {"type": "string"}}, {"name": "last_event_id", "in": "query", "schema": {"type": "string"}}],
+        "responses": {
+          "200": {"description": "Event stream", "content": {"text/event-stream": {"schema": {"type": "string"}}}},
+          "401": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/items/{id}": {
+      "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+      "get": {
+        "summary": "Get an item",
+        "parameters": [{"name": "If-None-Match", "in": "header", "schema": {"type": "string"}}],
+        "responses": {
+          "200": {"description": "The item", "headers": {"ETag": {"$ref": "#/components/headers/ETag"}}, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Item"}}}},
+          "304": {"description": "Not modified"},
+          "401": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"}
+        }
+      },
+      "patch": {
+        "summary": "Update an item",
+        "description": "A JSON merge patch (RFC 7396): fields present in the body replace the stored ones and null clears them; id and rev cannot be changed.",
+        "parameters": [{"$ref": "#/components/parameters/IfMatch"}],
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Item"}}, "application/merge-patch+json": {"schema": {"$ref": "#/components/schemas/Item"}}}},
+        "responses": {
+          "200": {"description": "The updated item", "headers": {"ETag": {"$ref": "#/components/headers/ETag"}}, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Item"}}}},
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"},
+          "412": {"$ref": "#/components/responses/Error"},
+          "422": {"$ref": "#/components/responses/Error"}
+        }
+      },
+      "delete": {
+        "summary": "Delete an item (it goes to the trash)",
+        "parameters": [{"$ref": "#/components/parameters/IfMatch"}],
+        "responses": {
+          "204": {"description": "Deleted"},
+          "401": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"},
+          "412": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    }
+  },
+  "components": {
+    "securitySchemes": {"bearer": {"type": "http", "scheme": "bearer"}},
+    "parameters": {
+      "IfMatch": {"name": "If-Match", "in": "header", "description": "ETag of the revision being changed; 412 if the item has moved on", "schema": {"type": "string"}}
+    },
+    "headers": {
+      "ETag": {"description": "The item's revision, quoted", "schema": {"type": "string"}}
+    },
+    "responses": {
+      "Error": {"description": "Error", "content": {"application/json": {"schema": {"type": "object", "properties": {"error": {"type": "string"}}}}}}
+    },
+    "schemas": {
+      "Item": {
+        "type": "object",
+        "required": ["title"],
+        "properties": {
+          "id": {"type": "string"},
+          "rev": {"type": "integer", "readOnly": true},
+          "title": {"type": "string"},
+          "done": {"type": "boolean"},
+          "status": {"type": "string", "enum": ["todo", "doing", "done"]},
+          "due": {"type": "string", "format": "date-time", "nullable": true},
+          "scheduled": {"type": "string", "format": "date-time", "nullable": true},
+          "focus": {"type": "array", "items": {"type": "object", "properties": {"start": {"type": "string", "format": "date-time"}, "end": {"type": "string", "format": "date-time"}}}},
+          "tags": {"type": "array", "items": {"type": "string"}},
+          "priority": {"type": "string", "enum": ["high", "medium", "low"]},
+          "created_at": {"type": "string", "format": "date-time", "nullable": true},
+          "completed_at": {"type": "string", "format": "date-time", "nullable": true},
+          "project": {"type": "string"},
+          "estimate_minutes": {"type": "integer"},
+          "time": {"type": "array", "items": {"type": "object", "properties": {"start": {"type": "string", "format": "date-time"}, "end": {"type": "string", "format": "date-time", "nullable": true}}}}
+        }
+      }
+    }
+  }
+}
+`
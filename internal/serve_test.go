@@ -0,0 +1,109 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// inTempDir runs the test in an empty directory with its own home, so the
+// data file, sidecars and credentials are all scratch files.
+func inTempDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("TADA_TOKEN", "")
+	t.Setenv("TADA_PROFILE", "")
+	t.Setenv("TADA_SYNC_URL", "")
+	prev := activeList
+	activeList = defaultListName
+	t.Cleanup(func() { activeList = prev })
+	return dir
+}
+
+// testServer starts `todo serve` over the scratch directory's list.
+func testServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	s := newServer("secret")
+	s.mu.Lock()
+	_, err := s.load()
+	s.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s.routes())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func request(t *testing.T, ts *httptest.Server, method, path, ifMatch, body string) (*http.Response, Item) {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer secret")
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var it Item
+	if resp.StatusCode != http.StatusNoContent {
+		json.NewDecoder(resp.Body).Decode(&it)
+	}
+	return resp, it
+}
+
+// Saving stamps merge metadata into the file; that must not look like an
+// edit made outside the API.
+func TestServeRevStableAcrossSaves(t *testing.T) {
+	inTempDir(t)
+	ts := testServer(t)
+
+	resp, it := request(t, ts, http.MethodPost, "/items", "", `{"title":"a"}`)
+	if resp.StatusCode != http.StatusCreated || it.Rev != 1 {
+		t.Fatalf("create: %d rev %d", resp.StatusCode, it.Rev)
+	}
+	resp, _ = request(t, ts, http.MethodGet, itemPath(it.ID), "", "")
+	if got := resp.Header.Get("ETag"); got != `"1"` {
+		t.Fatalf("ETag after create = %s, want \"1\"", got)
+	}
+	resp, it = request(t, ts, http.MethodPatch, itemPath(it.ID), `"1"`, `{"title":"b"}`)
+	if resp.StatusCode != http.StatusOK || it.Rev != 2 {
+		t.Fatalf("patch: %d rev %d", resp.StatusCode, it.Rev)
+	}
+	resp, _ = request(t, ts, http.MethodGet, itemPath(it.ID), "", "")
+	if got := resp.Header.Get("ETag"); got != `"2"` {
+		t.Fatalf("ETag after patch = %s, want \"2\"", got)
+	}
+}
+
+// PATCH is a merge patch: null clears a field, absent fields are kept.
+func TestServePatchNullClears(t *testing.T) {
+	inTempDir(t)
+	ts := testServer(t)
+
+	_, it := request(t, ts, http.MethodPost, "/items", "",
+		`{"title":"a","tags":["x"],"due":"2026-01-02T00:00:00Z","project":"p","priority":"high","estimate_minutes":30}`)
+	resp, it := request(t, ts, http.MethodPatch, itemPath(it.ID), `"1"`,
+		`{"tags":null,"due":null,"project":null,"priority":null,"estimate_minutes":null}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("patch: %d", resp.StatusCode)
+	}
+	if it.Title != "a" || len(it.Tags) != 0 || it.Due != nil || it.Project != "" || it.Priority != "" || it.EstimateMinutes != 0 {
+		t.Fatalf("fields not cleared: %+v", it)
+	}
+	if resp, _ := request(t, ts, http.MethodPatch, itemPath(it.ID), `"2"`, `{"nope":1}`); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unknown field: %d, want 400", resp.StatusCode)
+	}
+}
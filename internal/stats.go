@@ -0,0 +1,235 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Stats is the `todo stats` dashboard. Field names are the --json schema.
+type Stats struct {
+	Total          int     `json:"total"`
+	Done           int     `json:"done"`
+	Pending        int     `json:"pending"`
+	CompletionRate float64 `json:"completion_rate"` // done / total, 0..1
+
+	// AvgCompletionHours averages CompletedAt - CreatedAt over items that
+	// have both; nil when none do (e.g. only items from older builds).
+	AvgCompletionHours *float64 `json:"avg_completion_hours"`
+	StreakDays         int      `json:"streak_days"` // consecutive days with a completion, up to today
+
+	Weeks      []WeekStats  `json:"weeks"` // oldest first
+	Tags       []GroupStats `json:"tags"`
+	Priorities []GroupStats `json:"priorities"`
+}
+
+// WeekStats covers one Monday-to-Sunday week.
+type WeekStats struct {
+	Start     string  `json:"start"` // YYYY-MM-DD of the Monday
+	Created   int     `json:"created"`
+	Completed int     `json:"completed"`
+	Rate      float64 `json:"completion_rate"` // share of items existing by week end that were done by then
+}
+
+// GroupStats counts items sharing a tag or priority.
+type GroupStats struct {
+	Name  string `json:"name"`
+	Total int    `json:"total"`
+	Done  int    `json:"done"`
+}
+
+// weekStart returns local midnight of the Monday of t's week.
+func weekStart(t time.Time) time.Time {
+	d := dayStart(t)
+	return d.AddDate(0, 0, -((int(d.Weekday()) + 6) % 7))
+}
+
+func computeStats(items []Item, now time.Time, weeks int) Stats {
+	var s Stats
+	s.Total = len(items)
+	var sum time.Duration
+	var timed int
+	doneDays := map[string]bool{}
+	for _, it := range items {
+		if it.Done {
+			s.Done++
+		}
+		if it.CompletedAt != nil {
+			doneDays[it.CompletedAt.Local().Format(dateLayout)] = true
+			if it.CreatedAt != nil && it.CompletedAt.After(*it.CreatedAt) {
+				sum += it.CompletedAt.Sub(*it.CreatedAt)
+				timed++
+			}
+		}
+	}
+	s.Pending = s.Total - s.Done
+	if s.Total > 0 {
+		s.CompletionRate = float64(s.Done) / float64(s.Total)
+	}
+	if timed > 0 {
+		h := sum.Hours() / float64(timed)
+		s.AvgCompletionHours = &h
+	}
+
+	// a streak survives until the end of today, so start from yesterday
+	// when nothing has been completed yet today
+	day := dayStart(now)
+	if !doneDays[day.Format(dateLayout)] {
+		day = day.AddDate(0, 0, -1)
+	}
+	for doneDays[day.Format(dateLayout)] {
+		s.StreakDays++
+		day = day.AddDate(0, 0, -1)
+	}
+
+	first := weekStart(now).AddDate(0, 0, -7*(weeks-1))
+	for w := 0; w < weeks; w++ {
+		start := first.AddDate(0, 0, 7*w)
+		end := start.AddDate(0, 0, 7)
+		ws := WeekStats{Start: start.Format(dateLayout)}
+		var existing, doneByEnd int
+		for _, it := range items {
+			inWeek := func(t *time.Time) bool { return t != nil && !t.Before(start) && t.Before(end) }
+			if inWeek(it.CreatedAt) {
+				ws.Created++
+			}
+			if inWeek(it.CompletedAt) {
+				ws.Completed++
+			}
+			// items without CreatedAt predate tracking: count them as existing
+			if it.CreatedAt == nil || it.CreatedAt.Before(end) {
+				existing++
+				if it.Done && (it.CompletedAt == nil || it.CompletedAt.Before(end)) {
+					doneByEnd++
+				}
+			}
+		}
+		if existing > 0 {
+			ws.Rate = float64(doneByEnd) / float64(existing)
+		}
+		s.Weeks = append(s.Weeks, ws)
+	}
+
+	s.Tags = groupStats(items, func(it Item) []string { return it.Tags })
+	s.Priorities = groupStats(items, func(it Item) []string {
+		if it.Priority == "" {
+			return []string{"none"}
+		}
+		return []string{string(it.Priority)}
+	})
+	return s
+}
+
+// groupStats counts items per key, largest group first.
+func groupStats(items []Item, keys func(Item) []string) []GroupStats {
+	byName := map[string]*GroupStats{}
+	for _, it := range items {
+		for _, k := range keys(it) {
+			g := byName[k]
+			if g == nil {
+				g = &GroupStats{Name: k}
+				byName[k] = g
+			}
+			g.Total++
+			if it.Done {
+				g.Done++
+			}
+		}
+	}
+	out := make([]GroupStats, 0, len(byName))
+	for _, g := range byName {
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Total != out[j].Total {
+			return out[i].Total > out[j].Total
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// sparkline draws one block per value, scaled to the largest.
+func sparkline(vals []float64) string {
+	const ticks = "▁▂▃▄▅▆▇█"
+	blocks := []rune(ticks)
+	peak := 0.0
+	for _, v := range vals {
+		peak = max(peak, v)
+	}
+	var b strings.Builder
+	for _, v := range vals {
+		i := 0
+		if peak > 0 {
+			i = int(v / peak * float64(len(blocks)-1))
+		}
+		b.WriteRune(blocks[i])
+	}
+	return b.String()
+}
+
+func doStats(weeks int, asJSON bool) int {
+	items, err := Load()
+	if err != nil {
+		fail("load: " + err.Error())
+		return 1
+	}
+	s := computeStats(items, time.Now(), weeks)
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(s); err != nil {
+			fail("stats: " + err.Error())
+			return 1
+		}
+		return 0
+	}
+
+	var created, completed, rate []float64
+	for _, w := range s.Weeks {
+		created = append(created, float64(w.Created))
+		completed = append(completed, float64(w.Completed))
+		rate = append(rate, w.Rate)
+	}
+	avg := mutedStyle.Render("n/a")
+	if s.AvgCompletionHours != nil {
+		avg = (time.Duration(*s.AvgCompletionHours * float64(time.Hour))).Round(time.Minute).String()
+	}
+	last := func(ws []WeekStats, f func(WeekStats) int) int {
+		if len(ws) == 0 {
+			return 0
+		}
+		return f(ws[len(ws)-1])
+	}
+
+	lines := []string{
+		titleStyle.Render("Stats"),
+		fmt.Sprintf("%s %d  %s %d  %s %d",
+			successStyle.Render("✔"), s.Done, pendingStyle.Render("•"), s.Pending, accentStyle.Render("Total"), s.Total),
+		"Completion " + progressBar(s.Done, s.Total, 28),
+		fmt.Sprintf("Avg time to done  %s", avg),
+		fmt.Sprintf("Streak            %d day(s)", s.StreakDays),
+		"",
+		mutedStyle.Render(fmt.Sprintf("Last %d weeks (oldest → this week)", len(s.Weeks))),
+		fmt.Sprintf("Created    %s  %d this week", accentStyle.Render(sparkline(created)), last(s.Weeks, func(w WeekStats) int { return w.Created })),
+		fmt.Sprintf("Completed  %s  %d this week", successStyle.Render(sparkline(completed)), last(s.Weeks, func(w WeekStats) int { return w.Completed })),
+		fmt.Sprintf("Done rate  %s", pendingStyle.Render(sparkline(rate))),
+	}
+	for _, sec := range []struct {
+		title  string
+		groups []GroupStats
+	}{{"By tag", s.Tags}, {"By priority", s.Priorities}} {
+		if len(sec.groups) == 0 {
+			continue
+		}
+		lines = append(lines, "", mutedStyle.Render(sec.title))
+		for _, g := range sec.groups {
+			lines = append(lines, fmt.Sprintf("%-10s %s", truncate(g.Name, 10), progressBar(g.Done, g.Total, 20)))
+		}
+	}
+	panel(lines)
+	return 0
+}
@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// statsNow is a Thursday afternoon; its week starts on Monday 2026-03-02.
+var statsNow = time.Date(2026, 3, 5, 15, 0, 0, 0, time.UTC)
+
+// timeAt parses an RFC 3339 time for fixtures.
+func timeAt(s string) *time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return &t
+}
+
+func TestStatsStreak(t *testing.T) {
+	inUTC(t)
+	done := func(when ...string) []Item {
+		var items []Item
+		for _, w := range when {
+			items = append(items, Item{Title: w, Done: true, CompletedAt: timeAt(w)})
+		}
+		return items
+	}
+	for _, tc := range []struct {
+		name  string
+		items []Item
+		want  int
+	}{
+		{"nothing done", nil, 0},
+		{"today only", done("2026-03-05T09:00:00Z"), 1},
+		{"today and the two days before", done("2026-03-05T09:00:00Z", "2026-03-04T23:59:00Z", "2026-03-03T00:00:00Z"), 3},
+		{"a gap ends the streak", done("2026-03-05T09:00:00Z", "2026-03-04T10:00:00Z", "2026-03-02T10:00:00Z"), 2},
+		{"alive until tonight without one today", done("2026-03-04T10:00:00Z", "2026-03-03T10:00:00Z"), 2},
+		{"broken by a day without any", done("2026-03-03T10:00:00Z"), 0},
+		{"several on a day count once", done("2026-03-05T08:00:00Z", "2026-03-05T09:00:00Z"), 1},
+		{"done without a completion time", []Item{{Title: "legacy", Done: true}}, 0},
+	} {
+		if got := computeStats(tc.items, statsNow, 1).StreakDays; got != tc.want {
+			t.Errorf("%s: streak %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestStatsTotals(t *testing.T) {
+	inUTC(t)
+	items := []Item{
+		{Title: "a", Done: true, CreatedAt: timeAt("2026-03-01T09:00:00Z"), CompletedAt: timeAt("2026-03-03T09:00:00Z"), Tags: []string{"work"}, Priority: PriorityHigh},
+		{Title: "b", Done: true, CreatedAt: timeAt("2026-03-04T00:00:00Z"), CompletedAt: timeAt("2026-03-04T12:00:00Z"), Tags: []string{"home", "work"}},
+		{Title: "c", Tags: []string{"home"}, Priority: PriorityHigh},
+		{Title: "d", Done: true}, // from before dates were recorded
+		// clock skew: completed "before" it was created, left out of the average
+		{Title: "e", Done: true, CreatedAt: timeAt("2026-03-04T12:00:00Z"), CompletedAt: timeAt("2026-03-04T11:00:00Z"), Priority: PriorityLow},
+	}
+	s := computeStats(items, statsNow, 1)
+	if s.Total != 5 || s.Done != 4 || s.Pending != 1 || s.CompletionRate != 0.8 {
+		t.Errorf("totals %d/%d/%d rate %v, want 5/4/1 rate 0.8", s.Total, s.Done, s.Pending, s.CompletionRate)
+	}
+	if s.AvgCompletionHours == nil || *s.AvgCompletionHours != 30 {
+		t.Errorf("average %v, want 30h over a and b", s.AvgCompletionHours)
+	}
+	groups := func(gs []GroupStats) string {
+		var out []string
+		for _, g := range gs {
+			out = append(out, fmt.Sprintf("%s %d/%d", g.Name, g.Done, g.Total))
+		}
+		return strings.Join(out, ", ")
+	}
+	if got, want := groups(s.Tags), "home 1/2, work 2/2"; got != want {
+		t.Errorf("tags %s, want %s", got, want)
+	}
+	if got, want := groups(s.Priorities), "high 1/2, none 2/2, low 1/1"; got != want {
+		t.Errorf("priorities %s, want %s", got, want)
+	}
+
+	if s := computeStats(nil, statsNow, 2); s.CompletionRate != 0 || s.AvgCompletionHours != nil || len(s.Weeks) != 2 || s.Weeks[1].Rate != 0 {
+		t.Errorf("empty list: %+v", s)
+	}
+}
+
+func TestStatsWeeks(t *testing.T) {
+	inUTC(t)
+	items := []Item{
+		{Title: "legacy done", Done: true},
+		{Title: "legacy pending"},
+		{Title: "done, completion unknown", Done: true, CreatedAt: timeAt("2026-02-10T10:00:00Z")},
+		// completed on the first instant of the current week: not done by
+		// the end of the previous one
+		{Title: "x", Done: true, CreatedAt: timeAt("2026-02-25T10:00:00Z"), CompletedAt: timeAt("2026-03-02T00:00:00Z")},
+		{Title: "y", CreatedAt: timeAt("2026-03-04T10:00:00Z")},
+		{Title: "future", CreatedAt: timeAt("2026-03-09T00:00:00Z")}, // after every week
+	}
+	s := computeStats(items, statsNow, 3)
+	var got []string
+	for _, w := range s.Weeks {
+		got = append(got, fmt.Sprintf("%s +%d ✔%d %.2f", w.Start, w.Created, w.Completed, math.Round(w.Rate*100)/100))
+	}
+	want := []string{
+		"2026-02-16 +0 ✔0 0.67", // 2 of the 3 items existing by then
+		"2026-02-23 +1 ✔0 0.50",
+		"2026-03-02 +1 ✔1 0.60",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("weeks:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
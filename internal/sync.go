@@ -0,0 +1,633 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sync talks to a tada server (see `todo serve`) over its REST API:
+//
+//	GET    /items        -> {"items": [Item...]}
+//	POST   /items        Item -> 201 Item
+//	PATCH  /items/{id}   Item, If-Match: "<rev>" -> 200 Item | 412
+//	DELETE /items/{id}   If-Match: "<rev>" -> 204 | 412
+//
+// The server bumps Item.Rev on every change; a stale If-Match is a
+// conflict. The client remembers the items as of the last sync in
+// sync.json: whatever differs from that snapshot is queued for the next
+// push, so edits made offline are sent once the server is reachable.
+
+const (
+	syncFileName = "sync.json"
+
+	syncRetries = 4
+	syncBackoff = 250 * time.Millisecond
+	syncTimeout = 15 * time.Second
+)
+
+// SyncConfig is the "sync" section of the config file.
+type SyncConfig struct {
+	URL string `json:"url"` // server base URL, e.g. https://tada.example.com/api
+}
+
+// syncState is what the client remembers per list between syncs.
+type syncState struct {
+	Base      []Item     `json:"base"` // items as the server had them at the last sync
+	LastSync  *time.Time `json:"last_sync,omitempty"`
+	Conflicts []string   `json:"conflicts,omitempty"` // IDs refused by the server at the last push
+}
+
+func syncPath() (string, error) {
+	p, err := dataPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(p), syncFileName), nil
+}
+
+// loadSyncStates reads the state of every list, keyed by list name.
+func loadSyncStates() (map[string]syncState, error) {
+	p, err := syncPath()
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return map[string]syncState{}, nil
+		}
+		return nil, fmt.Errorf("read sync state: %w", err)
+	}
+	states := map[string]syncState{}
+	if err := json.Unmarshal(b, &states); err != nil {
+		return nil, fmt.Errorf("sync state json unmarshal: %w", err)
+	}
+	return states, nil
+}
+
+func saveSyncState(list string, st syncState) error {
+	states, err := loadSyncStates()
+	if err != nil {
+		return err
+	}
+	states[list] = st
+	p, err := syncPath()
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return fmt.Errorf("sync state json marshal: %w", err)
+	}
+	if err := writeFileAtomic(p, b, 0o644); err != nil {
+		return fmt.Errorf("write sync state: %w", err)
+	}
+	return nil
+}
+
+// ---------------------------------------------------
+// pending changes
+// ---------------------------------------------------
+
+type syncOp struct {
+	kind string // "create", "update" or "delete"
+	item Item   // local item; for deletes, the base item
+	base Item   // for updates, the item as the server last had it
+}
+
+// pendingOps lists local changes since base, in local order then deletes.
+func pendingOps(base, local []Item) []syncOp {
+	byID := itemsByID(base)
+	seen := map[string]bool{}
+	var ops []syncOp
+	for _, it := range local {
+		seen[it.ID] = true
+		b, known := byID[it.ID]
+		switch {
+		case !known:
+			ops = append(ops, syncOp{kind: "create", item: it})
+		case !sameItem(withoutList(b), withoutList(it)):
+			ops = append(ops, syncOp{kind: "update", item: it, base: b})
+		}
+	}
+	for _, it := range base {
+		if !seen[it.ID] {
+			ops = append(ops, syncOp{kind: "delete", item: it})
+		}
+	}
+	return ops
+}
+
+// withoutList drops the local list name, which the server does not know.
+func withoutList(it Item) Item {
+	it.List = ""
+	return it
+}
+
+// ---------------------------------------------------
+// HTTP client
+// ---------------------------------------------------
+
+// syncClient calls the server API with retries. Fields are injectable so a
+// test can point it at an httptest.Server and skip the real sleeps.
+type syncClient struct {
+	base    string
+	token   string
+	http    *http.Client
+	retries int
+	backoff time.Duration
+	sleep   func(time.Duration)
+
+	// refresh renews a rejected token (see oauth.go); nil if it can't be.
+	refresh func(stale string) (string, error)
+	mu      sync.Mutex // guards token, shared with the event stream
+}
+
+func newSyncClient(base, token string) *syncClient {
+	return &syncClient{
+		base:    strings.TrimRight(base, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: syncTimeout},
+		retries: syncRetries,
+		backoff: syncBackoff,
+		sleep:   time.Sleep,
+	}
+}
+
+// bearer returns the token to send.
+func (c *syncClient) bearer() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token
+}
+
+// reauth renews the token after a 401 for stale and reports whether there
+// is a new one to retry with.
+func (c *syncClient) reauth(stale string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != stale {
+		return true // renewed meanwhile
+	}
+	if c.refresh == nil {
+		return false
+	}
+	tok, err := c.refresh(stale)
+	if err != nil || tok == stale {
+		return false
+	}
+	c.token = tok
+	return true
+}
+
+// errOffline reports that the server could not be reached after retries.
+var errOffline = errors.New("server unreachable")
+
+// errConflict reports a 412: the item changed on the server since our rev.
+var errConflict = errors.New("changed on the server")
+
+// apiError is a non-retryable error response.
+type apiError struct {
+	status int
+	msg    string
+}
+
+func (e *apiError) Error() string { return fmt.Sprintf("server: %d %s", e.status, e.msg) }
+
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// do sends one request, retrying transport errors, 429 and 5xx with
+// exponential backoff and jitter (or the server's Retry-After). A 401 is
+// retried once after refreshing the token.
+func (c *syncClient) do(method, path string, ifMatch int, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	var lastErr error
+	reauthed := false
+	for attempt := 0; attempt < c.retries; attempt++ {
+		if attempt > 0 {
+			c.sleep(c.delay(attempt, lastErr))
+		}
+		req, err := http.NewRequest(method, c.base+path, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		token := c.bearer()
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Accept", "application/json")
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if ifMatch > 0 {
+			req.Header.Set("If-Match", etag(ifMatch))
+		}
+		resp, err := c.http.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("%w: %v", errOffline, err)
+			continue
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("%w: %v", errOffline, err)
+			continue
+		}
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized && !reauthed && c.reauth(token):
+			reauthed = true
+			attempt-- // not a failure: retry at once with the new token
+			continue
+		case retryable(resp.StatusCode):
+			lastErr = &retryAfterError{apiError{resp.StatusCode, serverMessage(data)}, resp.Header.Get("Retry-After")}
+			continue
+		case resp.StatusCode == http.StatusPreconditionFailed:
+			return errConflict
+		case resp.StatusCode >= 400:
+			return &apiError{resp.StatusCode, serverMessage(data)}
+		}
+		if out != nil && len(data) > 0 {
+			if err := json.Unmarshal(data, out); err != nil {
+				return fmt.Errorf("server response: %w", err)
+			}
+		}
+		return nil
+	}
+	return lastErr
+}
+
+// retryAfterError is a retryable response, with the server's Retry-After.
+type retryAfterError struct {
+	apiError
+	retryAfter string
+}
+
+func (c *syncClient) delay(attempt int, last error) time.Duration {
+	var ra *retryAfterError
+	if errors.As(last, &ra) {
+		if s, err := strconv.Atoi(ra.retryAfter); err == nil && s >= 0 {
+			return time.Duration(s) * time.Second
+		}
+	}
+	d := c.backoff << (attempt - 1)
+	return d/2 + rand.N(d/2+1)
+}
+
+// serverMessage extracts {"error": "..."} from a response body.
+func serverMessage(b []byte) string {
+	var e struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(b, &e) == nil && e.Error != "" {
+		return e.Error
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// etag formats a revision as a strong entity tag.
+func etag(rev int) string { return `"` + strconv.Itoa(rev) + `"` }
+
+func itemPath(id string) string { return "/items/" + url.PathEscape(id) }
+
+func (c *syncClient) list() ([]Item, error) {
+	var resp struct {
+		Items []Item `json:"items"`
+	}
+	if err := c.do(http.MethodGet, "/items", 0, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
+
+// send applies one pending change and returns the server's copy (nil for
+// deletes).
+func (c *syncClient) send(op syncOp) (*Item, error) {
+	it := withoutList(op.item)
+	switch op.kind {
+	case "create":
+		var out Item
+		err := c.do(http.MethodPost, "/items", 0, it, &out)
+		return &out, err
+	case "update":
+		var out Item
+		err := c.do(http.MethodPatch, itemPath(it.ID), it.Rev, updatePatch(withoutList(op.base), it), &out)
+		return &out, err
+	default:
+		err := c.do(http.MethodDelete, itemPath(it.ID), it.Rev, nil, nil)
+		var ae *apiError
+		if errors.As(err, &ae) && ae.status == http.StatusNotFound {
+			err = nil // already gone
+		}
+		return nil, err
+	}
+}
+
+// updatePatch is the merge patch turning base into it: the item's fields,
+// plus null for the ones it cleared (empty fields are omitted from JSON,
+// which a merge patch reads as "unchanged").
+func updatePatch(base, it Item) map[string]json.RawMessage {
+	var was, now map[string]json.RawMessage
+	b, _ := json.Marshal(base)
+	json.Unmarshal(b, &was)
+	b, _ = json.Marshal(it)
+	json.Unmarshal(b, &now)
+	for k := range was {
+		if _, kept := now[k]; !kept {
+			now[k] = json.RawMessage("null")
+		}
+	}
+	return now
+}
+
+// ---------------------------------------------------
+// push / pull / status
+// ---------------------------------------------------
+
+// syncURL picks the server: --url, then TADA_SYNC_URL, then the config.
+func syncURL(flag string) (string, error) {
+	if flag != "" {
+		return flag, nil
+	}
+	if u := os.Getenv("TADA_SYNC_URL"); u != "" {
+		return u, nil
+	}
+	if pr := activeProfile(); pr != nil && pr.Server != "" {
+		return pr.Server, nil
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		return "", err
+	}
+	if cfg.Sync.URL == "" {
+		return "", errors.New("no sync server configured (set sync.url in the config, TADA_SYNC_URL, a profile server or --url)")
+	}
+	return cfg.Sync.URL, nil
+}
+
+// syncPush sends pending changes. It stops at the first offline error and
+// leaves the rest queued; conflicts are recorded and skipped.
+func syncPush(c *syncClient, list string) (sent int, conflicts []string, queued int, err error) {
+	states, err := loadSyncStates()
+	if err != nil {
+		return 0, nil, 0, err
+	}
+	st := states[list]
+	local, err := loadList(list)
+	if err != nil {
+		return 0, nil, 0, err
+	}
+	ops := pendingOps(st.Base, local)
+	base := itemsByID(st.Base)
+	revs := map[string]int{}
+	var offline error
+send:
+	for i, op := range ops {
+		got, err := c.send(op)
+		switch {
+		case errors.Is(err, errConflict):
+			conflicts = append(conflicts, op.item.ID)
+			continue
+		case errors.Is(err, errOffline):
+			offline, queued = err, len(ops)-i
+			break send
+		case err != nil:
+			return sent, conflicts, len(ops) - i, fmt.Errorf("%s %q: %w", op.kind, op.item.Title, err)
+		}
+		sent++
+		if got == nil {
+			delete(base, op.item.ID)
+			continue
+		}
+		base[got.ID] = withoutList(*got)
+		revs[got.ID] = got.Rev
+	}
+
+	// record the new revisions locally; reload in case of concurrent edits
+	if len(revs) > 0 {
+		if local, err = loadList(list); err != nil {
+			return sent, conflicts, queued, err
+		}
+		for i := range local {
+			if r, has := revs[local[i].ID]; has {
+				local[i].Rev = r
+			}
+		}
+		if err := saveList(list, local); err != nil {
+			return sent, conflicts, queued, err
+		}
+	}
+	// keep the snapshot in list order so sync.json diffs stay readable
+	var snap []Item
+	for _, it := range local {
+		if b, has := base[it.ID]; has {
+			snap = append(snap, b)
+			delete(base, it.ID)
+		}
+	}
+	for _, it := range st.Base {
+		if b, has := base[it.ID]; has {
+			snap = append(snap, b) // deletes that did not go through
+		}
+	}
+	st.Base = snap
+	st.Conflicts = conflicts
+	if offline == nil {
+		now := time.Now()
+		st.LastSync = &now
+	}
+	if err := saveSyncState(list, st); err != nil {
+		return sent, conflicts, queued, err
+	}
+	return sent, conflicts, queued, offline
+}
+
+// syncPull merges the server's items into the list. Local edits win (with
+// theirs, the server wins); either way local items take the server's
+// revision, so the next push overwrites what was pulled instead of
+// conflicting again.
+func syncPull(c *syncClient, list string, theirs bool) (changed int, err error) {
+	remote, err := c.list()
+	if err != nil {
+		return 0, err
+	}
+	states, err := loadSyncStates()
+	if err != nil {
+		return 0, err
+	}
+	st := states[list]
+	local, err := loadList(list)
+	if err != nil {
+		return 0, err
+	}
+	for i := range local {
+		local[i] = withoutList(local[i]) // saveList puts it back
+	}
+	var merged []Item
+	if theirs {
+		merged = mergeItems(st.Base, remote, local)
+	} else {
+		merged = mergeItems(st.Base, local, remote)
+	}
+	remoteByID := itemsByID(remote)
+	for i := range merged {
+		if r, has := remoteByID[merged[i].ID]; has {
+			merged[i].Rev = r.Rev
+		}
+	}
+	before := itemsByID(local)
+	after := itemsByID(merged)
+	for _, it := range merged {
+		if b, has := before[it.ID]; !has || !sameItem(b, it) {
+			changed++
+		}
+	}
+	for _, it := range local {
+		if _, has := after[it.ID]; !has {
+			changed++
+		}
+	}
+	if err := saveList(list, merged); err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	st.Base, st.LastSync, st.Conflicts = remote, &now, nil
+	return changed, saveSyncState(list, st)
+}
+
+func doSyncStatus(c *syncClient, list string) int {
+	states, err := loadSyncStates()
+	if err != nil {
+		fail("sync: " + err.Error())
+		return 1
+	}
+	st := states[list]
+	local, err := loadList(list)
+	if err != nil {
+		fail("load: " + err.Error())
+		return 1
+	}
+	fmt.Printf("server:    %s\n", c.base)
+	fmt.Printf("list:      %s\n", list)
+	if st.LastSync != nil {
+		fmt.Printf("last sync: %s\n", st.LastSync.Local().Format("2006-01-02 15:04:05"))
+	} else {
+		fmt.Println("last sync: never")
+	}
+	counts := map[string]int{}
+	for _, op := range pendingOps(st.Base, local) {
+		counts[op.kind]++
+	}
+	fmt.Printf("queued:    %d created, %d updated, %d deleted\n", counts["create"], counts["update"], counts["delete"])
+	if n := len(st.Conflicts); n > 0 {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("conflicts: %d (run `todo sync pull`, then push)", n)))
+	}
+
+	c.retries = 1 // a quick look; push/pull retry
+	remote, err := c.list()
+	if err != nil {
+		fmt.Println(mutedStyle.Render("remote:    " + err.Error()))
+		return 0
+	}
+	changed := 0
+	base := itemsByID(st.Base)
+	for _, r := range remote {
+		if b, has := base[r.ID]; !has || b.Rev != r.Rev {
+			changed++
+		}
+	}
+	remoteBy := itemsByID(remote)
+	for _, b := range st.Base {
+		if _, has := remoteBy[b.ID]; !has {
+			changed++
+		}
+	}
+	fmt.Printf("remote:    %d items, %d changed since last sync\n", len(remote), changed)
+	return 0
+}
+
+const syncUsage = "usage: todo sync <push|pull [--theirs]|status> [--url URL] | todo sync --git"
+
+func doSync(a []string) int {
+	if len(a) == 1 && a[0] == "--git" {
+		return doSyncGit()
+	}
+	var sub, flagURL string
+	theirs := false
+	for i := 0; i < len(a); i++ {
+		switch {
+		case a[i] == "--url" && i+1 < len(a):
+			flagURL = a[i+1]
+			i++
+		case a[i] == "--theirs":
+			theirs = true
+		case sub == "" && (a[i] == "push" || a[i] == "pull" || a[i] == "status"):
+			sub = a[i]
+		default:
+			fail(syncUsage)
+			return 2
+		}
+	}
+	if sub == "" || (theirs && sub != "pull") {
+		fail(syncUsage)
+		return 2
+	}
+	base, err := syncURL(flagURL)
+	if err != nil {
+		fail("sync: " + err.Error())
+		return 2
+	}
+	ti, code := ensureAuth()
+	if code != 0 {
+		return code
+	}
+	c := newSyncClient(base, ti.Token)
+	c.refresh = tokenRefresher(ti)
+
+	switch sub {
+	case "status":
+		return doSyncStatus(c, activeList)
+	case "pull":
+		n, err := syncPull(c, activeList, theirs)
+		if err != nil {
+			fail("sync pull: " + err.Error())
+			return 1
+		}
+		ok(fmt.Sprintf("pulled: %d item(s) changed", n))
+		return 0
+	}
+	sent, conflicts, queued, err := syncPush(c, activeList)
+	switch {
+	case errors.Is(err, errOffline):
+		fail(fmt.Sprintf("sync push: %v; %d change(s) stay queued", err, queued))
+		fmt.Fprintln(os.Stderr, mutedStyle.Render("Hint: run `todo sync push` again when the server is reachable"))
+		return 1
+	case err != nil:
+		fail("sync push: " + err.Error())
+		return 1
+	}
+	if len(conflicts) > 0 {
+		fail(fmt.Sprintf("sync push: sent %d, %d conflict(s): changed on the server since your last pull", sent, len(conflicts)))
+		fmt.Fprintln(os.Stderr, mutedStyle.Render("Hint: `todo sync pull` merges them (your edits win), then push again"))
+		return 1
+	}
+	ok(fmt.Sprintf("pushed %d change(s)", sent))
+	return 0
+}
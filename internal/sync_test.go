@@ -0,0 +1,234 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSync is a stand-in for `todo serve`: an in-memory item store with
+// revisions, plus canned failures to hand out before real answers.
+type fakeSync struct {
+	mu       sync.Mutex
+	items    map[string]Item
+	order    []string
+	fail     []*http.Response // status and headers of the next answers
+	requests int
+}
+
+func newFakeSync(t *testing.T) (*fakeSync, *httptest.Server) {
+	f := &fakeSync{items: map[string]Item{}}
+	ts := httptest.NewServer(f)
+	t.Cleanup(ts.Close)
+	return f, ts
+}
+
+func (f *fakeSync) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests++
+	if len(f.fail) > 0 {
+		resp := f.fail[0]
+		f.fail = f.fail[1:]
+		for k, v := range resp.Header {
+			w.Header()[k] = v
+		}
+		writeError(w, resp.StatusCode, "canned failure")
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	id := strings.TrimPrefix(r.URL.Path, "/items/")
+	it, known := f.items[id]
+	if known && r.Header.Get("If-Match") != "" && !ifMatch(r, it.Rev) {
+		writeError(w, http.StatusPreconditionFailed, "stale")
+		return
+	}
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/items":
+		items := []Item{}
+		for _, id := range f.order {
+			items = append(items, f.items[id])
+		}
+		writeJSON(w, http.StatusOK, map[string][]Item{"items": items})
+	case r.Method == http.MethodPost:
+		var it Item
+		json.Unmarshal(body, &it)
+		it.Rev = 1
+		f.items[it.ID] = it
+		f.order = append(f.order, it.ID)
+		writeItem(w, http.StatusCreated, it)
+	case r.Method == http.MethodPatch && known:
+		next, err := mergePatch(it, body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		next.ID, next.Rev = it.ID, it.Rev+1
+		f.items[id] = next
+		writeItem(w, http.StatusOK, next)
+	case r.Method == http.MethodDelete && known:
+		delete(f.items, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusNotFound, "no such item")
+	}
+}
+
+// failNext queues canned answers with the given statuses.
+func (f *fakeSync) failNext(status int, header http.Header, n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for range n {
+		f.fail = append(f.fail, &http.Response{StatusCode: status, Header: header})
+	}
+}
+
+// testClient returns a client for ts that records its sleeps instead of
+// sleeping.
+func testClient(ts *httptest.Server) (*syncClient, *[]time.Duration) {
+	c := newSyncClient(ts.URL, "secret")
+	var slept []time.Duration
+	c.sleep = func(d time.Duration) { slept = append(slept, d) }
+	return c, &slept
+}
+
+func TestSyncRetriesWithBackoff(t *testing.T) {
+	inTempDir(t)
+	f, ts := newFakeSync(t)
+	c, slept := testClient(ts)
+	f.failNext(http.StatusServiceUnavailable, nil, 2)
+
+	if _, err := c.list(); err != nil {
+		t.Fatal(err)
+	}
+	if f.requests != 3 || len(*slept) != 2 {
+		t.Fatalf("requests %d, sleeps %v; want 3 and 2", f.requests, *slept)
+	}
+	for i, d := range *slept {
+		full := c.backoff << i
+		if d < full/2 || d > full {
+			t.Errorf("sleep %d = %v, want within [%v, %v]", i, d, full/2, full)
+		}
+	}
+
+	f.failNext(http.StatusServiceUnavailable, nil, c.retries)
+	if _, err := c.list(); err == nil {
+		t.Fatal("list succeeded after every attempt failed")
+	}
+}
+
+func TestSyncHonoursRetryAfter(t *testing.T) {
+	inTempDir(t)
+	f, ts := newFakeSync(t)
+	c, slept := testClient(ts)
+	f.failNext(http.StatusTooManyRequests, http.Header{"Retry-After": {"7"}}, 1)
+
+	if _, err := c.list(); err != nil {
+		t.Fatal(err)
+	}
+	if len(*slept) != 1 || (*slept)[0] != 7*time.Second {
+		t.Fatalf("sleeps %v, want [7s]", *slept)
+	}
+}
+
+func TestSyncPushConflict(t *testing.T) {
+	inTempDir(t)
+	f, ts := newFakeSync(t)
+	c, _ := testClient(ts)
+	if err := Save([]Item{newItem("a")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := syncPush(c, activeList); err != nil {
+		t.Fatal(err)
+	}
+
+	// someone else edits the item on the server
+	local, _ := Load()
+	id := local[0].ID
+	f.mu.Lock()
+	it := f.items[id]
+	it.Title, it.Rev = "theirs", it.Rev+1
+	f.items[id] = it
+	f.mu.Unlock()
+
+	local[0].Title = "ours"
+	if err := Save(local); err != nil {
+		t.Fatal(err)
+	}
+	sent, conflicts, _, err := syncPush(c, activeList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 0 || len(conflicts) != 1 || conflicts[0] != id {
+		t.Fatalf("sent %d, conflicts %v; want 0 and [%s]", sent, conflicts, id)
+	}
+	if f.items[id].Title != "theirs" {
+		t.Fatalf("server copy overwritten: %q", f.items[id].Title)
+	}
+}
+
+func TestSyncQueuesWhileOffline(t *testing.T) {
+	inTempDir(t)
+	f, ts := newFakeSync(t)
+	c, _ := testClient(ts)
+	if err := Save([]Item{newItem("a"), newItem("b")}); err != nil {
+		t.Fatal(err)
+	}
+
+	down := newSyncClient("http://127.0.0.1:1", "secret")
+	down.sleep = func(time.Duration) {}
+	sent, _, queued, err := syncPush(down, activeList)
+	if !errors.Is(err, errOffline) || sent != 0 || queued != 2 {
+		t.Fatalf("offline push: sent %d, queued %d, err %v", sent, queued, err)
+	}
+
+	sent, _, queued, err = syncPush(c, activeList)
+	if err != nil || sent != 2 || queued != 0 {
+		t.Fatalf("push: sent %d, queued %d, err %v", sent, queued, err)
+	}
+	if len(f.items) != 2 {
+		t.Fatalf("server has %d items, want 2", len(f.items))
+	}
+}
+
+// Clearing a field is sent as null; the server must end up without it and
+// nothing may stay pending.
+func TestSyncPushClearsFields(t *testing.T) {
+	inTempDir(t)
+	f, ts := newFakeSync(t)
+	c, _ := testClient(ts)
+	it := newItem("a")
+	due := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	it.Tags, it.Due, it.Project, it.Priority, it.EstimateMinutes = []string{"x"}, &due, "p", PriorityHigh, 30
+	if err := Save([]Item{it}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := syncPush(c, activeList); err != nil {
+		t.Fatal(err)
+	}
+
+	local, _ := Load()
+	local[0].Tags, local[0].Due, local[0].Project, local[0].Priority, local[0].EstimateMinutes = nil, nil, "", "", 0
+	if err := Save(local); err != nil {
+		t.Fatal(err)
+	}
+	if sent, _, _, err := syncPush(c, activeList); err != nil || sent != 1 {
+		t.Fatalf("push: sent %d, err %v", sent, err)
+	}
+	got := f.items[it.ID]
+	if len(got.Tags) != 0 || got.Due != nil || got.Project != "" || got.Priority != "" || got.EstimateMinutes != 0 {
+		t.Fatalf("server kept cleared fields: %+v", got)
+	}
+
+	states, _ := loadSyncStates()
+	local, _ = Load()
+	if ops := pendingOps(states[activeList].Base, local); len(ops) != 0 {
+		t.Fatalf("still pending after push: %d op(s), first %s", len(ops), ops[0].kind)
+	}
+}
@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// applyTags adds ("tag" or "+tag") and removes ("-tag") tags. Tags are
+// stored lower-case without a leading '#', sorted and unique.
+func applyTags(tags []string, changes []string) ([]string, error) {
+	out := append([]string(nil), tags...)
+	for _, c := range changes {
+		remove := strings.HasPrefix(c, "-")
+		t := strings.ToLower(strings.TrimLeft(c, "+-#"))
+		if t == "" || strings.ContainsAny(t, " \t,") {
+			return nil, fmt.Errorf("bad tag %q", c)
+		}
+		out = slices.DeleteFunc(out, func(x string) bool { return x == t })
+		if !remove {
+			out = append(out, t)
+		}
+	}
+	slices.Sort(out)
+	return out, nil
+}
+
+func doTag(userIndex int, changes []string) int {
+	items, err := Load()
+	if err != nil {
+		fail("load: " + err.Error())
+		return 1
+	}
+	if userIndex < 1 || userIndex > len(items) {
+		fail(fmt.Sprintf("index out of range: have %d, got %d", len(items), userIndex))
+		fmt.Fprintln(os.Stderr, mutedStyle.Render("Hint: run `todo ls` to see valid indexes"))
+		return 2
+	}
+	it := &items[userIndex-1]
+	tags, err := applyTags(it.Tags, changes)
+	if err != nil {
+		fail("tag: " + err.Error())
+		return 2
+	}
+	it.Tags = tags
+	if err := Save(items); err != nil {
+		fail("save: " + err.Error())
+		return 1
+	}
+	if len(tags) == 0 {
+		ok("no tags")
+	} else {
+		ok("tags: #" + strings.Join(tags, " #"))
+	}
+	return 0
+}
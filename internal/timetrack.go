@@ -0,0 +1,436 @@
+package internal
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TimeEntry is one tracked interval on an item. End is nil while the timer
+// is running; at most one entry across all items is open.
+type TimeEntry struct {
+	Start time.Time  `json:"start"`
+	End   *time.Time `json:"end,omitempty"`
+}
+
+// duration of the entry, counting an open entry up to now.
+func (e TimeEntry) duration(now time.Time) time.Duration {
+	if e.End == nil {
+		return now.Sub(e.Start)
+	}
+	return e.End.Sub(e.Start)
+}
+
+// activeTimer returns the position of the item with a running timer, or -1.
+func activeTimer(items []Item) int {
+	for i, it := range items {
+		if n := len(it.Time); n > 0 && it.Time[n-1].End == nil {
+			return i
+		}
+	}
+	return -1
+}
+
+// stopTimer closes the running entry of items[i] at now.
+func stopTimer(items []Item, i int, now time.Time) time.Duration {
+	e := &items[i].Time[len(items[i].Time)-1]
+	e.End = &now
+	return e.duration(now)
+}
+
+func trackedTime(it Item, now time.Time) time.Duration {
+	var d time.Duration
+	for _, e := range it.Time {
+		d += e.duration(now)
+	}
+	return d
+}
+
+// fmtClock renders a duration as h:mm.
+func fmtClock(d time.Duration) string {
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("%d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// ---------------------------------------------------
+// start / stop / project / estimate
+// ---------------------------------------------------
+
+// timerTitle names a timed item, adding its list when that is not the
+// active one.
+func timerTitle(it Item) string {
+	if l := itemList(it); l != activeList {
+		return it.Title + " (list " + l + ")"
+	}
+	return it.Title
+}
+
+// doStart and doStop work on every list, so that starting a timer stops
+// the one running in another list.
+func doStart(userIndex int) int {
+	all, err := loadAll()
+	if err != nil {
+		fail("load: " + err.Error())
+		return 1
+	}
+	var idx []int // positions in all of the active list's items
+	for i, it := range all {
+		if itemList(it) == activeList {
+			idx = append(idx, i)
+		}
+	}
+	if userIndex < 1 || userIndex > len(idx) {
+		fail(fmt.Sprintf("index out of range: have %d, got %d", len(idx), userIndex))
+		fmt.Fprintln(os.Stderr, mutedStyle.Render("Hint: run `todo ls` to see valid indexes"))
+		return 2
+	}
+	now := time.Now()
+	target := idx[userIndex-1]
+	if a := activeTimer(all); a == target {
+		ok("already tracking: " + all[a].Title)
+		return 0
+	} else if a >= 0 {
+		d := stopTimer(all, a, now)
+		fmt.Println(mutedStyle.Render(fmt.Sprintf("stopped %s after %s", timerTitle(all[a]), fmtClock(d))))
+	}
+	all[target].Time = append(all[target].Time, TimeEntry{Start: now})
+	if all[target].Status == StatusTodo {
+		all[target].Status = StatusDoing
+	}
+	if err := saveAll(all); err != nil {
+		fail("save: " + err.Error())
+		return 1
+	}
+	ok("tracking: " + all[target].Title)
+	return 0
+}
+
+func doStop() int {
+	all, err := loadAll()
+	if err != nil {
+		fail("load: " + err.Error())
+		return 1
+	}
+	a := activeTimer(all)
+	if a < 0 {
+		fail("stop: no timer is running")
+		fmt.Fprintln(os.Stderr, mutedStyle.Render("Hint: start one with `todo start <index>`"))
+		return 2
+	}
+	d := stopTimer(all, a, time.Now())
+	if err := saveAll(all); err != nil {
+		fail("save: " + err.Error())
+		return 1
+	}
+	ok(fmt.Sprintf("stopped %s after %s", timerTitle(all[a]), fmtClock(d)))
+	return 0
+}
+
+// doSetField sets the project, estimate or priority of an item; "none"
+// clears it.
+func doSetField(kind string, userIndex int, value string) int {
+	items, err := Load()
+	if err != nil {
+		fail("load: " + err.Error())
+		return 1
+	}
+	if userIndex < 1 || userIndex > len(items) {
+		fail(fmt.Sprintf("index out of range: have %d, got %d", len(items), userIndex))
+		fmt.Fprintln(os.Stderr, mutedStyle.Render("Hint: run `todo ls` to see valid indexes"))
+		return 2
+	}
+	it := &items[userIndex-1]
+	switch kind {
+	case "project":
+		if value == "none" {
+			value = ""
+		}
+		it.Project = value
+	case "estimate":
+		mins := 0
+		if value != "none" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d < time.Minute {
+				fail(fmt.Sprintf("estimate: bad duration %q (e.g. 90m, 2h)", value))
+				return 2
+			}
+			mins = int(d.Minutes())
+		}
+		it.EstimateMinutes = mins
+		value = fmtClock(time.Duration(mins) * time.Minute)
+	case "priority":
+		p, known := parsePriority(value)
+		if !known && value != "none" {
+			fail(fmt.Sprintf("priority: want high, medium, low or none, got %q", value))
+			return 2
+		}
+		it.Priority = p
+	}
+	if err := Save(items); err != nil {
+		fail("save: " + err.Error())
+		return 1
+	}
+	if value == "" || value == "none" || value == "0:00" {
+		ok(kind + " cleared")
+	} else {
+		ok(kind + " " + value)
+	}
+	return 0
+}
+
+// ---------------------------------------------------
+// report
+// ---------------------------------------------------
+
+const reportUsage = "usage: todo report [--day|--week|--month|--since <date>] [--by item|project|day] [--csv|--timeclock]"
+
+// reportOptions is the parsed form of `todo report` flags.
+type reportOptions struct {
+	since  time.Time // zero means all time
+	by     string    // "item", "project" or "day"
+	format string    // "" (table), "csv" or "timeclock"
+}
+
+func parseReportArgs(a []string, now time.Time) (reportOptions, error) {
+	opt := reportOptions{by: "item"}
+	for i := 0; i < len(a); i++ {
+		switch a[i] {
+		case "--day", "--today":
+			opt.since = dayStart(now)
+		case "--week":
+			d := dayStart(now)
+			opt.since = d.AddDate(0, 0, -((int(d.Weekday()) + 6) % 7)) // Monday
+		case "--month":
+			d := dayStart(now)
+			opt.since = d.AddDate(0, 0, 1-d.Day())
+		case "--since":
+			if i+1 >= len(a) {
+				return opt, fmt.Errorf(reportUsage)
+			}
+			i++
+			t, err := parseDate(a[i], now)
+			if err != nil {
+				return opt, err
+			}
+			opt.since = t
+		case "--by":
+			if i+1 >= len(a) {
+				return opt, fmt.Errorf(reportUsage)
+			}
+			i++
+			switch a[i] {
+			case "item", "project", "day":
+				opt.by = a[i]
+			default:
+				return opt, fmt.Errorf("report: --by wants item, project or day, got %q", a[i])
+			}
+		case "--csv":
+			opt.format = "csv"
+		case "--timeclock":
+			opt.format = "timeclock"
+		default:
+			return opt, fmt.Errorf(reportUsage)
+		}
+	}
+	return opt, nil
+}
+
+// timeRow is one tracked interval joined with its item, for reports.
+type timeRow struct {
+	Item  Item
+	Entry TimeEntry
+}
+
+func timeRows(items []Item, since time.Time) []timeRow {
+	var rows []timeRow
+	for _, it := range items {
+		for _, e := range it.Time {
+			if !e.Start.Before(since) {
+				rows = append(rows, timeRow{Item: it, Entry: e})
+			}
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Entry.Start.Before(rows[j].Entry.Start) })
+	return rows
+}
+
+func projectName(it Item) string {
+	if it.Project == "" {
+		return "(none)"
+	}
+	return it.Project
+}
+
+func doReport(opt reportOptions) int {
+	items, err := Load()
+	if err != nil {
+		fail("load: " + err.Error())
+		return 1
+	}
+	now := time.Now()
+	rows := timeRows(items, opt.since)
+	switch opt.format {
+	case "csv":
+		if err := writeTimeCSV(os.Stdout, rows, now); err != nil {
+			fail("report: " + err.Error())
+			return 1
+		}
+		return 0
+	case "timeclock":
+		writeTimeclock(os.Stdout, rows)
+		return 0
+	}
+	if len(rows) == 0 {
+		fmt.Println(mutedStyle.Render("no time tracked in this period"))
+		return 0
+	}
+
+	totals, sum := reportTotals(rows, opt.by, now)
+	for _, t := range totals {
+		label := t.key
+		if opt.by == "item" {
+			label = t.item.Title
+		}
+		line := fmt.Sprintf("  %7s  %s", fmtClock(t.d), label)
+		if opt.by == "item" {
+			line += estimateNote(t.item, now)
+		}
+		fmt.Println(line)
+	}
+	fmt.Println(mutedStyle.Render(fmt.Sprintf("  %7s  total", fmtClock(sum))))
+	return 0
+}
+
+// reportTotal is one line of the report table.
+type reportTotal struct {
+	key  string // item ID, project name or YYYY-MM-DD
+	item Item   // the first row's item, for titles and estimates
+	d    time.Duration
+}
+
+// reportTotals sums rows per item, project or day, in order of first
+// appearance, and returns the grand total.
+func reportTotals(rows []timeRow, by string, now time.Time) ([]reportTotal, time.Duration) {
+	var out []reportTotal
+	at := map[string]int{}
+	var sum time.Duration
+	for _, r := range rows {
+		var k string
+		switch by {
+		case "project":
+			k = projectName(r.Item)
+		case "day":
+			k = r.Entry.Start.Local().Format(dateLayout)
+		default:
+			k = r.Item.ID
+		}
+		i, seen := at[k]
+		if !seen {
+			i = len(out)
+			at[k] = i
+			out = append(out, reportTotal{key: k, item: r.Item})
+		}
+		d := r.Entry.duration(now)
+		out[i].d += d
+		sum += d
+	}
+	return out, sum
+}
+
+// estimateNote compares an item's estimate with all time tracked on it
+// (not just the report period), e.g. " (est 2:00, +0:15 over)".
+func estimateNote(it Item, now time.Time) string {
+	if it.EstimateMinutes == 0 {
+		return ""
+	}
+	est := time.Duration(it.EstimateMinutes) * time.Minute
+	actual := trackedTime(it, now)
+	diff := actual - est
+	switch {
+	case diff > 0:
+		return errorStyle.Render(fmt.Sprintf("  est %s, %s over", fmtClock(est), fmtClock(diff)))
+	default:
+		return mutedStyle.Render(fmt.Sprintf("  est %s, %s left", fmtClock(est), fmtClock(-diff)))
+	}
+}
+
+func writeTimeCSV(w io.Writer, rows []timeRow, now time.Time) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"date", "start", "end", "minutes", "project", "item", "estimate_minutes"}); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		end := ""
+		if r.Entry.End != nil {
+			end = r.Entry.End.Local().Format(time.RFC3339)
+		}
+		rec := []string{
+			r.Entry.Start.Local().Format(dateLayout),
+			r.Entry.Start.Local().Format(time.RFC3339),
+			end,
+			fmt.Sprint(int(r.Entry.duration(now).Minutes())),
+			r.Item.Project,
+			r.Item.Title,
+			fmt.Sprint(r.Item.EstimateMinutes),
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeTimeclock emits the timeclock format read by hledger and ledger:
+// "i <date> <time> <account>  <description>" / "o <date> <time>". The
+// project is the account; a running entry is left clocked in.
+func writeTimeclock(w io.Writer, rows []timeRow) {
+	const layout = "2006-01-02 15:04:05"
+	for _, r := range rows {
+		account := r.Item.Project
+		if account == "" {
+			account = "todo"
+		}
+		account = strings.Join(strings.Fields(account), " ") // two spaces end the account
+		fmt.Fprintf(w, "i %s %s  %s\n", r.Entry.Start.Local().Format(layout), account, r.Item.Title)
+		if r.Entry.End != nil {
+			fmt.Fprintf(w, "o %s\n", r.Entry.End.Local().Format(layout))
+		}
+	}
+}
+
+// ---------------------------------------------------
+// TUI header
+// ---------------------------------------------------
+
+// timerLabel describes the running timer for the list header, or "".
+// items is the shown list; away holds a timer running in another list.
+func timerLabel(items, away []Item, now time.Time) string {
+	a := activeTimer(items)
+	suffix := ""
+	if a < 0 {
+		if a = activeTimer(away); a < 0 {
+			return ""
+		}
+		items, suffix = away, " · "+itemList(away[a])
+	}
+	e := items[a].Time[len(items[a].Time)-1]
+	return fmt.Sprintf("⏱ %s %s%s", truncate(items[a].Title, 24), fmtClock(e.duration(now)), suffix)
+}
+
+// timersElsewhere returns the items of lists other than name with a
+// running timer (at most one), for the TUI header.
+func timersElsewhere(name string) []Item {
+	all, err := loadAll()
+	if err != nil {
+		return nil
+	}
+	if a := activeTimer(all); a >= 0 && itemList(all[a]) != name {
+		return all[a : a+1]
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package internal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Runs of spaces in a project collapse to one: timeclock readers end the
+// account at the first double space.
+func TestTimeclockAccount(t *testing.T) {
+	inUTC(t)
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	for project, account := range map[string]string{
+		"":                 "todo",
+		"Annual  review":   "Annual review",
+		"a   b    c":       "a b c",
+		" tabs\tand  more": "tabs and more",
+	} {
+		var buf bytes.Buffer
+		writeTimeclock(&buf, []timeRow{{Item: Item{Title: "x", Project: project}, Entry: TimeEntry{Start: start}}})
+		line := strings.TrimSuffix(buf.String(), "\n")
+		got, _, _ := strings.Cut(line[len("i 2026-03-02 10:00:00 "):], "  ")
+		if got != account {
+			t.Errorf("project %q: account %q, want %q (line %q)", project, got, account, line)
+		}
+	}
+}
+
+// inUTC pins local time to UTC, so dates and times print the same anywhere.
+func inUTC(t *testing.T) {
+	prev := time.Local
+	time.Local = time.UTC
+	t.Cleanup(func() { time.Local = prev })
+}
+
+func TestParseReportArgs(t *testing.T) {
+	inUTC(t)
+	now := time.Date(2026, 3, 5, 15, 0, 0, 0, time.UTC) // a Thursday
+	day := func(s string) time.Time { d, _ := time.Parse(dateLayout, s); return d }
+	for _, tc := range []struct {
+		args []string
+		want reportOptions
+	}{
+		{nil, reportOptions{by: "item"}},
+		{[]string{"--day"}, reportOptions{since: day("2026-03-05"), by: "item"}},
+		{[]string{"--week", "--by", "project"}, reportOptions{since: day("2026-03-02"), by: "project"}},
+		{[]string{"--month", "--csv"}, reportOptions{since: day("2026-03-01"), by: "item", format: "csv"}},
+		{[]string{"--since", "2026-02-14", "--by", "day", "--timeclock"}, reportOptions{since: day("2026-02-14"), by: "day", format: "timeclock"}},
+		{[]string{"--since", "yesterday"}, reportOptions{since: day("2026-03-04"), by: "item"}},
+	} {
+		got, err := parseReportArgs(tc.args, now)
+		if err != nil || !got.since.Equal(tc.want.since) || got.by != tc.want.by || got.format != tc.want.format {
+			t.Errorf("parseReportArgs(%q) = %+v, %v; want %+v", tc.args, got, err, tc.want)
+		}
+	}
+	for _, args := range [][]string{{"--since"}, {"--by"}, {"--by", "tag"}, {"--since", "someday"}, {"--weekly"}} {
+		if _, err := parseReportArgs(args, now); err == nil {
+			t.Errorf("parseReportArgs(%q) accepted", args)
+		}
+	}
+}
+
+// timeFixture has two items tracked over two days, one still running.
+func timeFixture() []Item {
+	at := func(s string) time.Time {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+	end := func(s string) *time.Time { t := at(s); return &t }
+	return []Item{
+		{ID: "a", Title: "Write report", Project: "Annual review", EstimateMinutes: 60, Time: []TimeEntry{
+			{Start: at("2026-03-02T10:00:00Z"), End: end("2026-03-02T10:45:00Z")},
+			{Start: at("2026-03-04T09:00:00Z"), End: end("2026-03-04T09:30:00Z")},
+		}},
+		{ID: "b", Title: "Plan trip", Time: []TimeEntry{
+			{Start: at("2026-03-03T14:00:00Z"), End: end("2026-03-03T14:20:00Z")},
+			{Start: at("2026-03-05T11:00:00Z")}, // running
+		}},
+		{ID: "c", Title: "Untracked"},
+	}
+}
+
+func TestTimeRows(t *testing.T) {
+	inUTC(t)
+	items := timeFixture()
+	var got []string
+	for _, r := range timeRows(items, time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)) {
+		got = append(got, r.Item.ID+"@"+r.Entry.Start.Format("02T15:04"))
+	}
+	if want := "b@03T14:00 a@04T09:00 b@05T11:00"; strings.Join(got, " ") != want {
+		t.Errorf("rows %v, want %s (since the 3rd, by start)", got, want)
+	}
+	if n := len(timeRows(items, time.Time{})); n != 4 {
+		t.Errorf("all-time rows = %d, want 4", n)
+	}
+}
+
+func TestTrackedTimeAndEstimate(t *testing.T) {
+	inUTC(t)
+	now := time.Date(2026, 3, 5, 11, 10, 0, 0, time.UTC)
+	items := timeFixture()
+	for i, want := range []string{"1:15", "0:30", "0:00"} {
+		if got := fmtClock(trackedTime(items[i], now)); got != want {
+			t.Errorf("%s: tracked %s, want %s", items[i].Title, got, want)
+		}
+	}
+	if note := estimateNote(items[0], now); !strings.Contains(note, "est 1:00, 0:15 over") {
+		t.Errorf("over estimate: %q", note)
+	}
+	items[0].EstimateMinutes = 90
+	if note := estimateNote(items[0], now); !strings.Contains(note, "est 1:30, 0:15 left") {
+		t.Errorf("under estimate: %q", note)
+	}
+	if note := estimateNote(items[1], now); note != "" {
+		t.Errorf("no estimate: %q", note)
+	}
+}
+
+func TestReportExports(t *testing.T) {
+	inUTC(t)
+	now := time.Date(2026, 3, 5, 11, 10, 0, 0, time.UTC)
+	rows := timeRows(timeFixture(), time.Time{})
+
+	var csv bytes.Buffer
+	if err := writeTimeCSV(&csv, rows, now); err != nil {
+		t.Fatal(err)
+	}
+	wantCSV := "date,start,end,minutes,project,item,estimate_minutes\n" +
+		"2026-03-02,2026-03-02T10:00:00Z,2026-03-02T10:45:00Z,45,Annual review,Write report,60\n" +
+		"2026-03-03,2026-03-03T14:00:00Z,2026-03-03T14:20:00Z,20,,Plan trip,0\n" +
+		"2026-03-04,2026-03-04T09:00:00Z,2026-03-04T09:30:00Z,30,Annual review,Write report,60\n" +
+		"2026-03-05,2026-03-05T11:00:00Z,,10,,Plan trip,0\n"
+	if csv.String() != wantCSV {
+		t.Errorf("csv:\n%s\nwant:\n%s", csv.String(), wantCSV)
+	}
+
+	var tc bytes.Buffer
+	writeTimeclock(&tc, rows)
+	wantTC := "i 2026-03-02 10:00:00 Annual review  Write report\no 2026-03-02 10:45:00\n" +
+		"i 2026-03-03 14:00:00 todo  Plan trip\no 2026-03-03 14:20:00\n" +
+		"i 2026-03-04 09:00:00 Annual review  Write report\no 2026-03-04 09:30:00\n" +
+		"i 2026-03-05 11:00:00 todo  Plan trip\n"
+	if tc.String() != wantTC {
+		t.Errorf("timeclock:\n%s\nwant:\n%s", tc.String(), wantTC)
+	}
+}
+
+func TestReportTotals(t *testing.T) {
+	inUTC(t)
+	now := time.Date(2026, 3, 5, 11, 10, 0, 0, time.UTC)
+	rows := timeRows(timeFixture(), time.Time{})
+	for _, tc := range []struct{ by, want string }{
+		{"item", "a 1:15, b 0:30"},
+		{"project", "Annual review 1:15, (none) 0:30"},
+		{"day", "2026-03-02 0:45, 2026-03-03 0:20, 2026-03-04 0:30, 2026-03-05 0:10"},
+	} {
+		totals, sum := reportTotals(rows, tc.by, now)
+		var got []string
+		for _, r := range totals {
+			got = append(got, r.key+" "+fmtClock(r.d))
+		}
+		if strings.Join(got, ", ") != tc.want || fmtClock(sum) != "1:45" {
+			t.Errorf("by %s: %v (total %s), want %s (total 1:45)", tc.by, got, fmtClock(sum), tc.want)
+		}
+	}
+}
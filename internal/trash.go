@@ -0,0 +1,242 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	trashFileName = "trash.json"
+
+	defaultTrashRetention = 30 * 24 * time.Hour
+)
+
+// TrashEntry is an item removed from the list, kept until restored, emptied
+// or purged after the retention period.
+type TrashEntry struct {
+	Item      Item      `json:"item"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// TrashConfig is the "trash" section of the config file.
+type TrashConfig struct {
+	RetentionDays int  `json:"retention_days"`      // 0 means 30 days, negative keeps forever
+	ConfirmBulk   bool `json:"confirm_bulk_delete"` // ask before deleting several items at once
+}
+
+func (c TrashConfig) retention() time.Duration {
+	switch {
+	case c.RetentionDays < 0:
+		return 0
+	case c.RetentionDays == 0:
+		return defaultTrashRetention
+	}
+	return time.Duration(c.RetentionDays) * 24 * time.Hour
+}
+
+// trashPath keeps the trash next to the data file.
+func trashPath() (string, error) {
+	p, err := dataPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(p), trashFileName), nil
+}
+
+func LoadTrash() ([]TrashEntry, error) {
+	p, err := trashPath()
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []TrashEntry{}, nil
+		}
+		return nil, fmt.Errorf("read trash: %w", err)
+	}
+	var entries []TrashEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, fmt.Errorf("trash json unmarshal: %w", err)
+	}
+	return entries, nil
+}
+
+func SaveTrash(entries []TrashEntry) error {
+	p, err := trashPath()
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("trash json marshal: %w", err)
+	}
+	if err := writeFileAtomic(p, b, 0o644); err != nil {
+		return fmt.Errorf("write trash: %w", err)
+	}
+	return nil
+}
+
+// purgeTrash drops entries older than retention (0 keeps everything).
+func purgeTrash(entries []TrashEntry, retention time.Duration, now time.Time) []TrashEntry {
+	if retention <= 0 {
+		return entries
+	}
+	out := entries[:0]
+	for _, e := range entries {
+		if now.Sub(e.DeletedAt) < retention {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// SaveTrashing replaces the stored list prev with items. Items of prev that
+// are missing from items go to the trash; trash entries whose item is back
+// in the list (restore, undo) are dropped.
+func SaveTrashing(prev, items []Item) error {
+	kept := itemsByID(items)
+	var removed []Item
+	for _, it := range prev {
+		if _, ok := kept[it.ID]; !ok {
+			removed = append(removed, it)
+		}
+	}
+
+	trash, err := LoadTrash()
+	if err != nil {
+		return err
+	}
+	changed := false
+	out := trash[:0]
+	for _, e := range trash {
+		if _, back := kept[e.Item.ID]; back {
+			changed = true
+			continue
+		}
+		out = append(out, e)
+	}
+	now := time.Now()
+	for _, it := range removed {
+		out = append(out, TrashEntry{Item: it, DeletedAt: now})
+		changed = true
+	}
+	if cfg, err := LoadConfig(); err == nil {
+		n := len(out)
+		out = purgeTrash(out, cfg.Trash.retention(), now)
+		changed = changed || len(out) != n
+	}
+	if changed {
+		if err := SaveTrash(out); err != nil {
+			return err
+		}
+	}
+	return Save(items)
+}
+
+// confirm asks a yes/no question on the terminal. Without a terminal on
+// stdin it answers no, so scripts must pass --yes explicitly.
+func confirm(question string) bool {
+	if fi, err := os.Stdin.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	fmt.Print(question + " [y/N] ")
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// ---------------------------------------------------
+// trash subcommands
+// ---------------------------------------------------
+
+func doTrashList() int {
+	entries, err := LoadTrash()
+	if err != nil {
+		fail("trash: " + err.Error())
+		return 1
+	}
+	if cfg, err := LoadConfig(); err == nil {
+		n := len(entries)
+		if entries = purgeTrash(entries, cfg.Trash.retention(), time.Now()); len(entries) != n {
+			if err := SaveTrash(entries); err != nil {
+				fail("trash: " + err.Error())
+				return 1
+			}
+		}
+	}
+	if len(entries) == 0 {
+		fmt.Println(mutedStyle.Render("trash is empty"))
+		return 0
+	}
+	for i, e := range entries {
+		fmt.Printf("  %3d  %s  %s\n", i+1, e.Item.Title,
+			mutedStyle.Render("deleted "+e.DeletedAt.Local().Format("2006-01-02 15:04")))
+	}
+	return 0
+}
+
+func doTrashRestore(userIndex int) int {
+	entries, err := LoadTrash()
+	if err != nil {
+		fail("trash: " + err.Error())
+		return 1
+	}
+	if userIndex < 1 || userIndex > len(entries) {
+		fail(fmt.Sprintf("index out of range: have %d, got %d", len(entries), userIndex))
+		fmt.Fprintln(os.Stderr, mutedStyle.Render("Hint: run `todo trash ls` to see valid indexes"))
+		return 2
+	}
+	// back into the list it was deleted from, unless that list is gone
+	e := entries[userIndex-1]
+	if names, err := listNames(); err == nil && slices.Contains(names, itemList(e.Item)) {
+		activeList = itemList(e.Item)
+	}
+	items, err := Load()
+	if err != nil {
+		fail("load: " + err.Error())
+		return 1
+	}
+	restored := append(append([]Item(nil), items...), e.Item)
+	if err := SaveTrashing(items, restored); err != nil {
+		fail("save: " + err.Error())
+		return 1
+	}
+	ok("restored to " + activeList)
+	return 0
+}
+
+func doTrashEmpty(yes bool) int {
+	entries, err := LoadTrash()
+	if err != nil {
+		fail("trash: " + err.Error())
+		return 1
+	}
+	if len(entries) == 0 {
+		ok("trash is already empty")
+		return 0
+	}
+	if !yes {
+		cfg, _ := LoadConfig()
+		if cfg.Trash.ConfirmBulk && !confirm(fmt.Sprintf("Permanently delete %d items?", len(entries))) {
+			fail("trash empty: cancelled (pass --yes to skip the prompt)")
+			return 2
+		}
+	}
+	if err := SaveTrash([]TrashEntry{}); err != nil {
+		fail("trash: " + err.Error())
+		return 1
+	}
+	ok("trash emptied")
+	return 0
+}
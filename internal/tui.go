@@ -3,10 +3,8 @@ package internal
 import (
 	"fmt"
 	"io"
-	"os"
 	"strings"
-	"syscall"
-	"unsafe"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
@@ -17,14 +15,30 @@ import (
 
 // listItem adapts our Item to bubbles/list.Item
 type listItem struct {
-	Text string
-	Done bool
+	Key    int // session-local identity, stable across moves and filtering
+	Text   string
+	Done   bool
+	Status Status
+	Base   Item // the stored item; carries fields the list does not edit
+}
+
+func newListItem(key int, it Item) listItem {
+	return listItem{Key: key, Text: it.Title, Done: it.Done, Status: it.Status, Base: it}
+}
+
+// item converts back to the domain model, applying the list's edits.
+func (i listItem) item() Item {
+	it := i.Base
+	it.Title, it.Done, it.Status = i.Text, i.Done, i.Status
+	return it
 }
 
 func (i listItem) TitleText() string {
 	box := boxUnchecked
 	if i.Done {
 		box = boxChecked
+	} else if i.Status == StatusDoing {
+		box = boxDoing
 	}
 	return fmt.Sprintf("%s %s", box, i.Text)
 }
@@ -34,29 +48,95 @@ func (i listItem) Title() string       { return i.TitleText() }
 func (i listItem) Description() string { return "" }
 func (i listItem) FilterValue() string { return i.Text }
 
+// setStatus updates Status and keeps Done in sync with it.
+func (i *listItem) setStatus(s Status) {
+	if done := s == StatusDone; done != i.Done {
+		i.Base.CompletedAt = completionTime(done)
+	}
+	i.Status = s
+	i.Done = s == StatusDone
+}
+
 type modelTUI struct {
 	list     list.Model
+	keys     keyMap
 	changed  bool
 	itemsRef *[]Item // pointer to original slice to write back updates
 
+	// Incremental saving (see autosave.go)
+	rev       int    // bumped by every local mutation
+	saveSeq   int    // rev of the latest scheduled save; older ticks are dropped
+	saving    bool   // a save is in flight
+	saveState string // "saving…", "saved" or the last error, for the status bar
+	gate      *saveGate
+
+	// Terminal size from tea.WindowSizeMsg (see layout.go)
+	width, height int
+
+	// Live reload (see watch.go)
+	base  []Item    // items as last read from disk; ancestor for merges
+	stamp fileStamp // data file version base was read from
+
+	// Server subscription for `todo ls --live` (see events.go); nil otherwise
+	live *liveSync
+
 	// Inline add
 	adding bool            // true when inline add is active
 	ti     textinput.Model // shared text input model (used for add & edit)
 	addErr string          // last add validation error (shown briefly)
 
 	// Inline edit
-	editing   bool // true when inline edit is active
-	editIndex int  // index of item being edited
-	editErr   string
+	editing bool // true when inline edit is active
+	editKey int  // key of item being edited
+	editErr string
+
+	// Bulk tag/priority prompt for the targets: "tag" or "priority" while
+	// active, "" otherwise
+	bulk    string
+	bulkErr string
+
+	// Multi-select: keys of marked items (shared with the delegate)
+	selected map[int]bool
+	nextKey  int
+
+	// Bulk delete confirmation (trash.confirm_bulk_delete)
+	confirmBulk bool
+	confirming  bool // waiting for y/n before deleting the targets
+
+	// Mouse drag-to-reorder (see mouse.go)
+	dragKey   int // key of the row being dragged; 0 when not dragging
+	dragMoved bool
 
-	// Undo support (single-level)
-	canUndo   bool
-	undoIndex int
-	undoItem  *listItem
+	// Undo support (single-level): list snapshot taken before the last
+	// toggle/delete/move, so a bulk action reverts as one step.
+	undo []list.Item
+
+	// Board view (see board.go)
+	board    bool
+	boardCol int
+	boardRow int
+	boardMsg string         // last board notice (e.g. WIP limit reached)
+	wip      map[Status]int // per-column WIP limits; 0 means unlimited
+
+	// Calendar view (see calendar.go)
+	cal      bool
+	calDay   time.Time // day under the cursor
+	calDrill bool      // show the selected day's items
+
+	// Named lists (see lists.go), shown as tabs when there are several
+	lists []string
+	away  []Item // a timer running in another list, for the header
+
+	// Focus timer (see focus.go); nil when not running
+	focus      *focusModel
+	focusLen   time.Duration
+	focusBreak time.Duration
 }
 
 // Custom delegate to control how items render (single line)
-type itemDelegate struct{}
+type itemDelegate struct {
+	selected map[int]bool
+}
 
 func (d itemDelegate) Height() int                               { return 1 }
 func (d itemDelegate) Spacing() int                              { return 0 }
@@ -71,39 +151,67 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, item list.Ite
 	box, text := raw[:space], strings.TrimSpace(raw[space:])
 
 	boxStyled := mutedStyle.Render(box)
+	if it.Status == StatusDoing {
+		boxStyled = pendingStyle.Render(box)
+	}
 	textStyled := text
+	if d.selected[it.Key] {
+		textStyled = accentStyle.Render(text)
+	}
 	if it.Done {
 		boxStyled = successStyle.Render(boxChecked)
 		textStyled = doneStyle.Render(text)
 	}
 
 	line := fmt.Sprintf("%s %s", boxStyled, textStyled)
+	if meta := itemMeta(it.Base); meta != "" {
+		line += " " + mutedStyle.Render(meta)
+	}
 	prefix := "  "
+	if d.selected[it.Key] {
+		prefix = accentStyle.Render("* ")
+	}
 	if index == m.Index() {
 		prefix = selectedStyle.Render("> ")
 	}
-	fmt.Fprintln(w, prefix+line)
+	// no trailing newline: the list separates rows itself, and mouse
+	// hit-testing relies on one line per row
+	fmt.Fprint(w, prefix+line)
 }
 
-// runInteractiveList starts the Bubble Tea list and persists changes when quitting.
-func runInteractiveList(items []Item, opt Options) error {
-	li := make([]list.Item, 0, len(items))
-	for _, it := range items {
-		li = append(li, listItem{Text: it.Title, Done: it.Done})
+// itemMeta renders an item's priority and tags as "!high #work".
+func itemMeta(it Item) string {
+	var parts []string
+	if it.Priority != "" {
+		parts = append(parts, "!"+string(it.Priority))
+	}
+	for _, t := range it.Tags {
+		parts = append(parts, "#"+t)
 	}
+	return strings.Join(parts, " ")
+}
 
-	l := list.New(li, itemDelegate{}, 0, 0)
+// runInteractiveList starts the Bubble Tea list. Edits are saved as they happen
+// (see autosave.go); anything still unsaved is written when quitting.
+func runInteractiveList(items []Item, opt Options, cfg Config) error {
+	keys, err := newKeyMap(cfg.Keymap)
+	if err != nil {
+		return err
+	}
+	focusLen, focusBreak, err := cfg.Focus.durations()
+	if err != nil {
+		return err
+	}
 
-	// Header title with live counts
-	dn, pn := stats(items)
-	ltitle := fmt.Sprintf("%s   %s %d  %s %d  %s %d",
-		titleStyle.Render("Todos"),
-		successStyle.Render("✔"), dn,
-		pendingStyle.Render("•"), pn,
-		accentStyle.Render("Total"), len(items),
-	)
+	li := make([]list.Item, 0, len(items))
+	for i, it := range items {
+		li = append(li, newListItem(i+1, it))
+	}
 
-	l.Title = ltitle
+	selected := map[int]bool{}
+	l := list.New(li, itemDelegate{selected: selected}, 0, 0)
+
+	l.Title = headerTitle(items, nil, time.Now())
 	l.SetShowHelp(true)
 	l.SetShowPagination(true)
 	l.SetShowStatusBar(true)
@@ -112,26 +220,58 @@ func runInteractiveList(items []Item, opt Options) error {
 	l.Styles.HelpStyle = helpStyle
 	l.Styles.PaginationStyle = helpStyle
 	l.FilterInput.Prompt = "/ "
+	// Keep matches in manual order so reordering works while filtered.
+	l.Filter = list.UnsortedFilter
 	l.SetStatusBarItemName("item", "items")
 
-	// Extend help with Add / Edit / Undo bindings
-	addBind := key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "add"))
-	editBind := key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "edit"))
-	undoBind := key.NewBinding(key.WithKeys("u"), key.WithHelp("u", "undo"))
-	l.AdditionalShortHelpKeys = func() []key.Binding { return []key.Binding{addBind, editBind, undoBind} }
-	l.AdditionalFullHelpKeys = func() []key.Binding { return []key.Binding{addBind, editBind, undoBind} }
+	// Navigation and quit come from the keymap too, so help matches reality
+	l.KeyMap = keys.listKeyMap()
+	l.AdditionalShortHelpKeys = keys.shortHelp
+	l.AdditionalFullHelpKeys = keys.fullHelp
 
 	m := modelTUI{
-		list:     l,
-		itemsRef: &items,
+		list:        l,
+		itemsRef:    &items,
+		base:        items,
+		selected:    selected,
+		nextKey:     len(items) + 1,
+		keys:        keys,
+		confirmBulk: cfg.Trash.ConfirmBulk,
+		board:       opt.Board,
+		wip:         opt.WIP,
+		focusLen:    focusLen,
+		focusBreak:  focusBreak,
+		gate:        &saveGate{},
 	}
+	stamp, err := storeStamp()
+	if err != nil {
+		return err
+	}
+	m.stamp = stamp
+	if m.lists, err = listNames(); err != nil {
+		return err
+	}
+	m.away = timersElsewhere(activeList)
+	if opt.Live {
+		if m.live, err = newLiveSync(); err != nil {
+			return err
+		}
+		m.refreshStatus()
+		m.live.start()
+		defer m.live.stop()
+	}
+	m.relayout()
 	// set up text input for inline add/edit
 	m.ti = textinput.New()
 	m.ti.Prompt = "> "
 	m.ti.Placeholder = "New item title..."
 	m.ti.CharLimit = 200
 
-	p := tea.NewProgram(m, tea.WithAltScreen())
+	popts := []tea.ProgramOption{tea.WithAltScreen()}
+	if cfg.TUI.mouseEnabled() {
+		popts = append(popts, tea.WithMouseCellMotion())
+	}
+	p := tea.NewProgram(m, popts...)
 	finalModel, err := p.Run()
 	if err != nil {
 		return err
@@ -141,26 +281,112 @@ func runInteractiveList(items []Item, opt Options) error {
 		return nil
 	}
 
-	// Write back list state to items and persist if changed
-	if fm.changed {
-		out := make([]Item, 0, len(fm.list.Items()))
-		for _, it := range fm.list.Items() {
-			if li, ok := it.(listItem); ok {
-				out = append(out, Item{Title: li.Text, Done: li.Done})
-			}
+	// Write back list state, merged with anything written to the file
+	// since the last poll, and persist if changed. A background save or
+	// live sync still running finishes first, so it cannot land after this
+	// one or the push below.
+	fm.gate.close()
+	defer fm.gate.mu.Unlock()
+	if fm.changed || fm.saving {
+		disk, err := Load()
+		if err != nil {
+			return err
 		}
-		if err := Save(out); err != nil {
+		out := mergeItems(fm.base, fm.currentItems(), disk)
+		if err := SaveTrashing(disk, out); err != nil {
 			return err
 		}
-		ok("saved")
+	}
+	// send the last edits; if the server is unreachable they stay queued
+	// for the next `todo sync push`
+	if fm.live != nil {
+		fm.live.client.retries = 1
+		syncPush(fm.live.client, fm.live.list)
 	}
 	return nil
 }
 
 // Update and View implement Bubble Tea's Model on modelTUI
-func (m modelTUI) Init() tea.Cmd { return nil }
+func (m modelTUI) Init() tea.Cmd {
+	if m.live != nil {
+		return tea.Batch(pollStore(m.stamp), m.live.next())
+	}
+	return pollStore(m.stamp)
+}
 
+// Update tracks the terminal size, keeps the layout in step with the
+// current mode and persists local mutations made by update through a
+// debounced save.
 func (m modelTUI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if ws, isSize := msg.(tea.WindowSizeMsg); isSize {
+		m.width, m.height = ws.Width, ws.Height
+	}
+	rev := m.rev
+	nm, cmd := m.update(msg)
+	next, isTUI := nm.(modelTUI)
+	if !isTUI {
+		return nm, cmd
+	}
+	next.relayout()
+	next.list.Title = headerTitle(next.currentItems(), next.away, time.Now())
+	if next.rev != rev {
+		cmd = tea.Batch(cmd, next.scheduleSave())
+	}
+	return next, cmd
+}
+
+func (m modelTUI) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// external changes to the data file, in every mode
+	if sm, isStore := msg.(storeMsg); isStore {
+		if sm.list != activeList {
+			return m, pollStore(m.stamp)
+		}
+		m.stamp = sm.stamp
+		var cmd tea.Cmd
+		if sm.changed {
+			cmd = m.applyExternal(sm.items)
+			if names, err := listNames(); err == nil {
+				m.lists = names
+			}
+			m.away = timersElsewhere(activeList)
+		}
+		return m, tea.Batch(cmd, pollStore(m.stamp))
+	}
+	switch sm := msg.(type) {
+	case saveDueMsg:
+		return m, m.startSave(sm)
+	case savedMsg:
+		cmd := m.finishSave(sm)
+		if sm.err == nil && m.live != nil {
+			cmd = tea.Batch(cmd, m.requestLiveSync())
+		}
+		return m, cmd
+	case liveStateMsg, liveEventMsg, liveSyncedMsg:
+		return m, m.updateLive(sm)
+	case focusTickMsg:
+		if m.focus == nil {
+			return m, nil
+		}
+		f, cmd := m.focus.Update(sm)
+		m.focus = &f
+		return m, cmd
+	case focusDoneMsg:
+		return m, m.addFocusSession(sm)
+	}
+
+	if mm, isMouse := msg.(tea.MouseMsg); isMouse {
+		return m.updateMouse(mm)
+	}
+
+	// pending bulk delete: y confirms, any other key cancels
+	if km, isKey := msg.(tea.KeyMsg); isKey && m.confirming {
+		m.confirming = false
+		if km.String() == "y" || km.String() == "Y" {
+			return m, m.removeTargets()
+		}
+		return m, nil
+	}
+
 	// add mode
 	if m.adding {
 		var cmd tea.Cmd
@@ -173,8 +399,13 @@ func (m modelTUI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.addErr = "Title cannot be empty"
 					return m, nil
 				}
-				m.list.InsertItem(m.list.Index()+1, listItem{Text: title, Done: false})
-				m.changed = true
+				at := 0
+				if i, ok := m.current(); ok {
+					at = i + 1
+				}
+				m.list.InsertItem(at, newListItem(m.nextKey, newItem(title)))
+				m.nextKey++
+				m.touch()
 				m.ti.SetValue("")
 				m.ti.Blur()
 				m.adding = false
@@ -202,11 +433,12 @@ func (m modelTUI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.editErr = "Title cannot be empty"
 					return m, nil
 				}
-				if m.editIndex >= 0 && m.editIndex < len(m.list.Items()) {
-					if li, ok := m.list.Items()[m.editIndex].(listItem); ok {
+				for i, it := range m.list.Items() {
+					if li, ok := it.(listItem); ok && li.Key == m.editKey {
 						li.Text = title
-						m.list.SetItem(m.editIndex, li)
-						m.changed = true
+						m.list.SetItem(i, li)
+						m.touch()
+						break
 					}
 				}
 				m.ti.SetValue("")
@@ -224,46 +456,80 @@ func (m modelTUI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, cmd
 	}
 
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "q", "esc":
-			return m, tea.Quit
-		case " ":
-			i := m.list.Index()
-			if i >= 0 && i < len(m.list.Items()) {
-				if li, ok := m.list.Items()[i].(listItem); ok {
-					li.Done = !li.Done
-					m.list.SetItem(i, li)
-					m.changed = true
+	// tag/priority prompt
+	if m.bulk != "" {
+		var cmd tea.Cmd
+		switch x := msg.(type) {
+		case tea.KeyMsg:
+			switch x.String() {
+			case "enter":
+				cmd, err := m.setTargets(strings.TrimSpace(m.ti.Value()))
+				if err != nil {
+					m.bulkErr = err.Error()
+					return m, nil
 				}
+				m.bulk = ""
+				m.ti.SetValue("")
+				m.ti.Blur()
+				return m, cmd
+			case "esc":
+				m.bulk = ""
+				m.ti.SetValue("")
+				m.ti.Blur()
+				return m, nil
 			}
-			return m, nil
-		case "d":
-			i := m.list.Index()
-			if i >= 0 && i < len(m.list.Items()) {
-				if li, ok := m.list.Items()[i].(listItem); ok {
-					tmp := li
-					m.undoItem = &tmp
-					m.undoIndex = i
-					m.canUndo = true
-				}
-				m.list.RemoveItem(i)
-				m.changed = true
+		}
+		m.ti, cmd = m.ti.Update(msg)
+		return m, cmd
+	}
+
+	// while typing a filter, keys belong to the filter input
+	if m.list.SettingFilter() {
+		var cmd tea.Cmd
+		m.list, cmd = m.list.Update(msg)
+		return m, cmd
+	}
+
+	if m.board {
+		return m.updateBoard(msg)
+	}
+	if m.cal {
+		return m.updateCalendar(msg)
+	}
+	if m.focus != nil {
+		return m.updateFocus(msg)
+	}
+
+	if msg, isKey := msg.(tea.KeyMsg); isKey {
+		switch {
+		case msg.String() == "esc":
+			// clear the selection first, then an applied filter, then quit
+			if len(m.selected) > 0 {
+				m.clearSelection()
+				return m, nil
 			}
-			return m, nil
-		case "a":
+			if m.list.IsFiltered() {
+				m.list.ResetFilter()
+				return m, nil
+			}
+			return m, tea.Quit
+		case key.Matches(msg, m.keys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.keys.Toggle):
+			return m, m.toggleTargets()
+		case key.Matches(msg, m.keys.Delete):
+			return m, m.deleteTargets()
+		case key.Matches(msg, m.keys.Add):
 			m.adding = true
 			m.ti.SetValue("")
 			m.ti.Placeholder = "New item title..."
 			m.ti.Focus()
 			return m, nil
-		case "e":
-			i := m.list.Index()
-			if i >= 0 && i < len(m.list.Items()) {
+		case key.Matches(msg, m.keys.Edit):
+			if i, ok := m.current(); ok {
 				if li, ok := m.list.Items()[i].(listItem); ok {
 					m.editing = true
-					m.editIndex = i
+					m.editKey = li.Key
 					m.ti.SetValue(li.Text)
 					m.ti.CursorEnd()
 					m.ti.Placeholder = "Edit item title..."
@@ -272,21 +538,60 @@ func (m modelTUI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 			return m, nil
-		case "u":
-			if m.canUndo && m.undoItem != nil {
-				idx := m.undoIndex
-				if idx < 0 {
-					idx = 0
-				}
-				if idx > len(m.list.Items()) {
-					idx = len(m.list.Items())
+		case key.Matches(msg, m.keys.Tag), key.Matches(msg, m.keys.Priority):
+			if len(m.targets()) == 0 {
+				return m, nil
+			}
+			m.bulk, m.bulkErr = "tag", ""
+			m.ti.Placeholder = "tag -tag …"
+			if key.Matches(msg, m.keys.Priority) {
+				m.bulk = "priority"
+				m.ti.Placeholder = "high, medium, low or none"
+			}
+			m.ti.SetValue("")
+			m.ti.Focus()
+			return m, nil
+		case key.Matches(msg, m.keys.MoveUp):
+			return m, m.moveTargets(-1)
+		case key.Matches(msg, m.keys.MoveDown):
+			return m, m.moveTargets(1)
+		case key.Matches(msg, m.keys.Select):
+			if li, ok := m.list.SelectedItem().(listItem); ok {
+				if m.selected[li.Key] {
+					delete(m.selected, li.Key)
+				} else {
+					m.selected[li.Key] = true
 				}
-				m.list.InsertItem(idx, *m.undoItem)
-				m.changed = true
-				m.canUndo = false
-				m.undoItem = nil
+				m.refreshStatus()
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.SelectVisible):
+			m.selectVisible()
+			return m, nil
+		case key.Matches(msg, m.keys.SelectRangeUp), key.Matches(msg, m.keys.SelectRangeDn):
+			m.markCurrent()
+			if key.Matches(msg, m.keys.SelectRangeUp) {
+				m.list.CursorUp()
+			} else {
+				m.list.CursorDown()
 			}
+			m.markCurrent()
 			return m, nil
+		case key.Matches(msg, m.keys.Board):
+			m.board = true
+			return m, nil
+		case key.Matches(msg, m.keys.Calendar):
+			m.cal = true
+			m.calDay = dayStart(time.Now())
+			return m, nil
+		case key.Matches(msg, m.keys.Focus):
+			return m, m.startFocus()
+		case key.Matches(msg, m.keys.NextList):
+			return m, m.switchList(1)
+		case key.Matches(msg, m.keys.PrevList):
+			return m, m.switchList(-1)
+		case key.Matches(msg, m.keys.Undo):
+			return m, m.undoLast()
 		}
 	}
 	var cmd tea.Cmd
@@ -294,17 +599,324 @@ func (m modelTUI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// current returns the global index of the item under the cursor. Global
+// indices address m.list.Items() and stay valid while a filter is applied.
+func (m modelTUI) current() (int, bool) {
+	if m.list.SelectedItem() == nil {
+		return 0, false
+	}
+	i := m.list.GlobalIndex()
+	return i, i >= 0 && i < len(m.list.Items())
+}
+
+// targets returns the global indices an action applies to: every selected
+// item, or the item under the cursor when nothing is selected.
+func (m modelTUI) targets() []int {
+	if len(m.selected) == 0 {
+		if i, ok := m.current(); ok {
+			return []int{i}
+		}
+		return nil
+	}
+	var out []int
+	for i, it := range m.list.Items() {
+		if li, ok := it.(listItem); ok && m.selected[li.Key] {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// visibleIndices maps visible positions to global indices. With
+// list.UnsortedFilter the result is ascending.
+func (m modelTUI) visibleIndices() []int {
+	pos := make(map[int]int, len(m.list.Items()))
+	for i, it := range m.list.Items() {
+		if li, ok := it.(listItem); ok {
+			pos[li.Key] = i
+		}
+	}
+	vis := m.list.VisibleItems()
+	out := make([]int, 0, len(vis))
+	for _, it := range vis {
+		if li, ok := it.(listItem); ok {
+			out = append(out, pos[li.Key])
+		}
+	}
+	return out
+}
+
+func (m *modelTUI) snapshot() {
+	m.undo = append([]list.Item(nil), m.list.Items()...)
+}
+
+// undoLast restores the snapshot taken before the last mutation.
+func (m *modelTUI) undoLast() tea.Cmd {
+	if m.undo == nil {
+		return nil
+	}
+	cmd := m.list.SetItems(m.undo)
+	m.undo = nil
+	m.clearSelection()
+	m.touch()
+	return cmd
+}
+
+// toggleTargets marks all targets done, or reopens them if all are done.
+func (m *modelTUI) toggleTargets() tea.Cmd {
+	idx := m.targets()
+	if len(idx) == 0 {
+		return nil
+	}
+	m.snapshot()
+	out := append([]list.Item(nil), m.list.Items()...)
+	done := false
+	for _, i := range idx {
+		if li, ok := out[i].(listItem); ok && !li.Done {
+			done = true
+			break
+		}
+	}
+	st := StatusTodo
+	if done {
+		st = StatusDone
+	}
+	for _, i := range idx {
+		if li, ok := out[i].(listItem); ok {
+			li.setStatus(st)
+			out[i] = li
+		}
+	}
+	m.touch()
+	return m.list.SetItems(out)
+}
+
+// setTargets applies the tag/priority prompt's input to every target as one
+// undo step: tag changes use the CLI syntax (tag, +tag, -tag), a priority
+// of "none" or nothing clears it.
+func (m *modelTUI) setTargets(input string) (tea.Cmd, error) {
+	var set func(*Item)
+	switch m.bulk {
+	case "tag":
+		changes := strings.Fields(input)
+		if len(changes) == 0 {
+			return nil, fmt.Errorf("no tags given")
+		}
+		if _, err := applyTags(nil, changes); err != nil {
+			return nil, err
+		}
+		set = func(it *Item) { it.Tags, _ = applyTags(it.Tags, changes) }
+	case "priority":
+		p, known := parsePriority(strings.ToLower(input))
+		if !known && input != "" && !strings.EqualFold(input, "none") {
+			return nil, fmt.Errorf("unknown priority %q", input)
+		}
+		set = func(it *Item) { it.Priority = p }
+	}
+	idx := m.targets()
+	if len(idx) == 0 || set == nil {
+		return nil, nil
+	}
+	m.snapshot()
+	out := append([]list.Item(nil), m.list.Items()...)
+	for _, i := range idx {
+		if li, ok := out[i].(listItem); ok {
+			set(&li.Base)
+			out[i] = li
+		}
+	}
+	m.touch()
+	return m.list.SetItems(out), nil
+}
+
+// deleteTargets removes the targets, asking first for several items when
+// confirmation is configured. Deleted items reach the trash on save.
+func (m *modelTUI) deleteTargets() tea.Cmd {
+	if n := len(m.targets()); n > 1 && m.confirmBulk {
+		m.confirming = true
+		return nil
+	}
+	return m.removeTargets()
+}
+
+func (m *modelTUI) removeTargets() tea.Cmd {
+	idx := m.targets()
+	if len(idx) == 0 {
+		return nil
+	}
+	m.snapshot()
+	drop := make(map[int]bool, len(idx))
+	for _, i := range idx {
+		drop[i] = true
+	}
+	items := m.list.Items()
+	out := make([]list.Item, 0, len(items)-len(idx))
+	for i, it := range items {
+		if !drop[i] {
+			out = append(out, it)
+		}
+	}
+	m.clearSelection()
+	m.touch()
+	if len(out) == 0 {
+		m.list.ResetFilter()
+	}
+	cmd := m.list.SetItems(out)
+	if n := len(m.list.VisibleItems()); n > 0 && m.list.Index() >= n {
+		m.list.Select(n - 1)
+	}
+	return cmd
+}
+
+// moveTargets moves the targets one visible position up (delta -1) or down
+// (delta +1). Adjacent targets move as a block; the permutation is applied
+// to the visible slots only, so hidden items keep their positions and the
+// move stays correct while a filter is applied.
+func (m *modelTUI) moveTargets(delta int) tea.Cmd {
+	pos := m.visibleIndices()
+	if len(pos) == 0 {
+		return nil
+	}
+	items := m.list.Items()
+	order := make([]list.Item, len(pos))
+	marked := make([]bool, len(pos))
+	for k, p := range pos {
+		order[k] = items[p]
+		if li, ok := items[p].(listItem); ok {
+			marked[k] = m.selected[li.Key]
+		}
+	}
+	cur := m.list.Index()
+	if len(m.selected) == 0 && cur < len(marked) {
+		marked[cur] = true
+	}
+	curKey := -1
+	if li, ok := m.list.SelectedItem().(listItem); ok {
+		curKey = li.Key
+	}
+
+	swap := func(a, b int) {
+		order[a], order[b] = order[b], order[a]
+		marked[a], marked[b] = marked[b], marked[a]
+	}
+	moved := false
+	if delta < 0 {
+		for k := 1; k < len(order); k++ {
+			if marked[k] && !marked[k-1] {
+				swap(k, k-1)
+				moved = true
+			}
+		}
+	} else {
+		for k := len(order) - 2; k >= 0; k-- {
+			if marked[k] && !marked[k+1] {
+				swap(k, k+1)
+				moved = true
+			}
+		}
+	}
+	if !moved {
+		return nil
+	}
+
+	m.snapshot()
+	out := append([]list.Item(nil), items...)
+	for k, p := range pos {
+		out[p] = order[k]
+	}
+	m.touch()
+	cmd := m.list.SetItems(out)
+	for k, it := range order {
+		if li, ok := it.(listItem); ok && li.Key == curKey {
+			m.list.Select(k)
+			break
+		}
+	}
+	return cmd
+}
+
+func (m *modelTUI) markCurrent() {
+	if li, ok := m.list.SelectedItem().(listItem); ok {
+		m.selected[li.Key] = true
+		m.refreshStatus()
+	}
+}
+
+// selectVisible selects every visible item, or clears them if all are
+// already selected.
+func (m *modelTUI) selectVisible() {
+	all := true
+	for _, it := range m.list.VisibleItems() {
+		if li, ok := it.(listItem); ok && !m.selected[li.Key] {
+			all = false
+			break
+		}
+	}
+	for _, it := range m.list.VisibleItems() {
+		if li, ok := it.(listItem); ok {
+			if all {
+				delete(m.selected, li.Key)
+			} else {
+				m.selected[li.Key] = true
+			}
+		}
+	}
+	m.refreshStatus()
+}
+
+// clearSelection empties the selection in place; the delegate shares the map.
+func (m *modelTUI) clearSelection() {
+	clear(m.selected)
+	m.refreshStatus()
+}
+
+// refreshStatus shows the selection count next to the item count in the
+// list's status bar.
+func (m *modelTUI) refreshStatus() {
+	suffix := ""
+	if n := len(m.selected); n > 0 {
+		suffix += fmt.Sprintf(" · %d selected", n)
+	}
+	if m.saveState != "" {
+		suffix += " · " + m.saveState
+	}
+	if m.live != nil {
+		suffix += " · " + m.live.state
+	}
+	m.list.SetStatusBarItemName("item"+suffix, "items"+suffix)
+}
+
 func (m modelTUI) View() string {
-	w, h := widthHeight()
-	listHeight := h - 4
-	if m.adding || m.editing {
-		listHeight = h - 6
+	lay := m.layout()
+	if m.board {
+		return m.panel(m.boardView(lay.innerW, lay.innerH))
+	}
+	if m.cal {
+		return m.panel(m.calendarView())
+	}
+	if m.focus != nil {
+		return m.panel(m.focus.View(lay.innerW))
 	}
-	m.list.SetSize(w-2, listHeight)
 
 	content := m.list.View()
+	if m.confirming {
+		bar := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("9")).Padding(0, 1).Width(lay.innerW - 2)
+		q := fmt.Sprintf("Delete %d items? They can be restored with `todo trash restore`.", len(m.targets()))
+		content = content + "\n" + bar.Render(errorStyle.Render("Confirm")+"\n"+q+"  "+helpStyle.Render("y yes • any key no"))
+	}
+	if m.bulk != "" {
+		bar := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("8")).Padding(0, 1).Width(lay.innerW - 2)
+		title := fmt.Sprintf("Tag %d item(s)", len(m.targets()))
+		if m.bulk == "priority" {
+			title = fmt.Sprintf("Set priority of %d item(s)", len(m.targets()))
+		}
+		if m.bulkErr != "" {
+			title += " — " + errorStyle.Render(m.bulkErr)
+		}
+		content = content + "\n" + bar.Render(title+"\n"+m.ti.View())
+	}
 	if m.adding || m.editing {
-		bar := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("8")).Padding(0, 1)
+		bar := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("8")).Padding(0, 1).Width(lay.innerW - 2)
 		title := "Add new item"
 		if m.editing {
 			title = "Edit item"
@@ -318,7 +930,7 @@ func (m modelTUI) View() string {
 		inputLine := title + "\n" + m.ti.View()
 		content = content + "\n" + bar.Render(inputLine)
 	}
-	return panelString(content)
+	return m.panel(content)
 }
 
 // helpers for View
@@ -330,27 +942,40 @@ func panelString(inner string) string {
 	return border.Render(inner)
 }
 
-func widthHeight() (int, int) {
-	w, h := 80, 24
-	if tw, th, err := termSize(); err == nil {
-		w, h = tw, th
+// panel is panelString with the list tabs drawn into the top border when
+// there is more than one list. It keeps the same height and insets, so the
+// layout and mouse offsets do not change.
+func (m modelTUI) panel(inner string) string {
+	if len(m.lists) < 2 {
+		return panelString(inner)
+	}
+	body := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder(), false, true, true, true).
+		BorderForeground(lipgloss.Color("8")).
+		Padding(0, 1).
+		Render(inner)
+	tabs := listTabs(m.lists, activeList)
+	fill := lipgloss.Width(body) - 3 - lipgloss.Width(tabs) // "╭─" + tabs + fill + "╮"
+	if fill < 0 {
+		return panelString(inner)
 	}
-	return w, h
+	edge := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	return edge.Render("╭─") + tabs + edge.Render(strings.Repeat("─", fill)+"╮") + "\n" + body
 }
 
-// portable terminal size
-func termSize() (int, int, error) {
-	fd := int(os.Stdout.Fd())
-	type winsize struct {
-		Row, Col, Xpixel, Ypixel uint16
-	}
-	ws := &winsize{}
-	_, _, err := syscall.Syscall(syscall.SYS_IOCTL,
-		uintptr(fd), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(ws)))
-	if err != 0 {
-		return 0, 0, fmt.Errorf("ioctl: %v", err)
+// headerTitle is the list title: live counts plus the running timer.
+func headerTitle(items, away []Item, now time.Time) string {
+	dn, pn := stats(items)
+	t := fmt.Sprintf("%s   %s %d  %s %d  %s %d",
+		titleStyle.Render("Todos"),
+		successStyle.Render("✔"), dn,
+		pendingStyle.Render("•"), pn,
+		accentStyle.Render("Total"), len(items),
+	)
+	if tl := timerLabel(items, away, now); tl != "" {
+		t += "   " + accentStyle.Render(tl)
 	}
-	return int(ws.Col), int(ws.Row), nil
+	return t
 }
 
 // small list stats used for the header
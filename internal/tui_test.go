@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// testModel is the list view over items with the default keymap.
+func testModel(t *testing.T, items ...Item) modelTUI {
+	t.Helper()
+	keys, err := newKeyMap(KeymapConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	li := make([]list.Item, len(items))
+	for i, it := range items {
+		li[i] = newListItem(i+1, it)
+	}
+	selected := map[int]bool{}
+	m := modelTUI{
+		list:     list.New(li, itemDelegate{selected: selected}, 80, 20),
+		keys:     keys,
+		selected: selected,
+		nextKey:  len(items) + 1,
+		ti:       textinput.New(),
+		gate:     &saveGate{},
+	}
+	m.list.Filter = list.UnsortedFilter
+	return m
+}
+
+// press feeds keys (and typed text) to m as key messages.
+func press(m modelTUI, keys ...string) modelTUI {
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		next, _ := m.Update(msg)
+		m = next.(modelTUI)
+	}
+	return m
+}
+
+func TestBulkTagAndPriority(t *testing.T) {
+	a, b, c := newItem("a"), newItem("b"), newItem("c")
+	a.Tags = []string{"old"}
+	m := testModel(t, a, b, c)
+	m = press(m, "V") // select all three
+
+	m = press(m, "#", "w", "o", "r", "k", " ", "-", "o", "l", "d", "enter")
+	m = press(m, "!", "h", "i", "g", "h", "enter")
+	for _, it := range m.currentItems() {
+		if !slices.Equal(it.Tags, []string{"work"}) || it.Priority != PriorityHigh {
+			t.Fatalf("%s: tags %v, priority %q", it.Title, it.Tags, it.Priority)
+		}
+	}
+
+	// a bad priority is reported and changes nothing
+	m = press(m, "!", "x", "enter")
+	if m.bulkErr == "" || m.bulk == "" {
+		t.Fatal("bad priority accepted")
+	}
+	m = press(m, "esc")
+
+	// one undo reverts the whole priority change, another is not kept
+	m = press(m, "u")
+	for _, it := range m.currentItems() {
+		if it.Priority != "" || !slices.Equal(it.Tags, []string{"work"}) {
+			t.Fatalf("after undo %s: tags %v, priority %q", it.Title, it.Tags, it.Priority)
+		}
+	}
+}
@@ -23,6 +23,7 @@ var (
 
 	boxChecked   = "☑"
 	boxUnchecked = "☐"
+	boxDoing     = "◐"
 )
 
 func ok(msg string) {
@@ -31,6 +32,9 @@ func ok(msg string) {
 func fail(msg string) {
 	fmt.Fprintln(os.Stderr, errorStyle.Render("✖ "+msg))
 }
+func warn(msg string) {
+	fmt.Fprintln(os.Stderr, pendingStyle.Render("! "+msg))
+}
 
 func panel(lines []string) {
 	border := lipgloss.NewStyle().
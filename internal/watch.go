@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// storePollInterval is how often the TUI checks the data file for changes
+// made by other processes (e.g. `todo add` in another terminal).
+const storePollInterval = time.Second
+
+// storeMsg reports the data file after a poll. items is only set when the
+// file changed since stamp was last seen.
+type storeMsg struct {
+	list    string // list the poll read; stale after switching lists
+	stamp   fileStamp
+	changed bool
+	items   []Item
+}
+
+// pollStore waits one interval, then checks the data file against last.
+// Polling keeps the binary free of platform-specific watchers and works on
+// network filesystems where inotify does not.
+func pollStore(last fileStamp) tea.Cmd {
+	name := activeList
+	return tea.Tick(storePollInterval, func(time.Time) tea.Msg {
+		st, err := storeStamp()
+		if err != nil || st == last {
+			return storeMsg{list: name, stamp: last}
+		}
+		items, err := loadList(name)
+		if err != nil {
+			// likely a partial write; retry on the next tick
+			return storeMsg{list: name, stamp: last}
+		}
+		return storeMsg{list: name, stamp: st, changed: true, items: items}
+	})
+}
+
+// currentItems converts the list back to domain items, in list order.
+func (m modelTUI) currentItems() []Item {
+	out := make([]Item, 0, len(m.list.Items()))
+	for _, it := range m.list.Items() {
+		if li, ok := it.(listItem); ok {
+			out = append(out, li.item())
+		}
+	}
+	return out
+}
+
+// applyExternal merges items read from disk into the list. Unsaved local
+// edits win over the file; the cursor, selection and any open add/edit
+// input are kept. The undo snapshot is dropped because it predates the
+// external change.
+func (m *modelTUI) applyExternal(remote []Item) tea.Cmd {
+	local := m.currentItems()
+	merged := mergeItems(m.base, local, remote)
+	m.base = remote
+	m.changed = !sameItems(merged, remote)
+	if sameItems(merged, local) {
+		return nil
+	}
+
+	keyByID := make(map[string]int, len(m.list.Items()))
+	for _, it := range m.list.Items() {
+		if li, ok := it.(listItem); ok {
+			keyByID[li.Base.ID] = li.Key
+		}
+	}
+	curKey := -1
+	if li, ok := m.list.SelectedItem().(listItem); ok {
+		curKey = li.Key
+	}
+
+	out := make([]list.Item, len(merged))
+	live := make(map[int]bool, len(merged))
+	curIdx := -1
+	for i, it := range merged {
+		k, found := keyByID[it.ID]
+		if !found {
+			k = m.nextKey
+			m.nextKey++
+		}
+		live[k] = true
+		if k == curKey {
+			curIdx = i
+		}
+		out[i] = newListItem(k, it)
+	}
+	for k := range m.selected {
+		if !live[k] {
+			delete(m.selected, k)
+		}
+	}
+	m.refreshStatus()
+	m.undo = nil
+
+	cmd := m.list.SetItems(out)
+	if curIdx >= 0 && !m.list.IsFiltered() {
+		m.list.Select(curIdx)
+	}
+	return tea.Batch(cmd, m.list.NewStatusMessage("reloaded external changes"))
+}
@@ -11,6 +11,7 @@ import (
 func main() {
 	// Root flags (apply to every subcommand)
 	groupPending := flag.Bool("group", false, "group output by pending/done")
+	listName := flag.String("l", os.Getenv("TADA_LIST"), "named list to use (default list if empty)")
 	flag.Parse()
 
 	// Hand the remaining args to the CLI runner.
@@ -22,6 +23,7 @@ func main() {
 
 	code := internal.Run(args, internal.Options{
 		Group: *groupPending,
+		List:  *listName,
 	})
 	if code != 0 {
 		fmt.Fprintln(os.Stderr)